@@ -0,0 +1,169 @@
+package vedis
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// OnBackupProgress sets the function called by Backup and BackupTo before each key is copied and once all of them are,
+// with the number of keys copied so far and the total, nil removing it.
+// It runs on the goroutine of the backup and may write to the datastore, see Backup.
+func (v *Vedis) OnBackupProgress(fn func(done, total int)) {
+	v.progress = fn
+}
+
+// Backup writes a snapshot of the datastore to a new datastore file at dstPath,
+// encrypted and compressed like the datastore itself.
+// The snapshot holds the keys as they were when Backup was called, while the datastore remains writable:
+// a write made during the backup, from the progress function for instance, first copies in memory
+// the keys it changes that were not read yet, so the memory used only grows with the keys written.
+// In manual commit mode, the snapshot holds the writes not flushed yet, see ManualCommit.
+// The copy is then reopened and compared with the snapshot, failing with ErrBackupMismatch if they differ.
+// dstPath is only replaced once the copy was verified.
+func (v *Vedis) Backup(dstPath string) error {
+	if dstPath == ":mem:" {
+		return errors.New("can not back up to an in-memory datastore")
+	}
+	tmp := dstPath + ".backup"
+	os.Remove(tmp)
+	os.Remove(tmp + journalSuffix)
+	if err := v.backup(tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dstPath)
+}
+
+// BackupTo writes a snapshot of the datastore to w, as a stream loaded back by Restore,
+// which verifies the checksum ending the stream.
+// The snapshot holds the keys as they were when BackupTo was called, see Backup.
+func (v *Vedis) BackupTo(w io.Writer) error {
+	_, err := v.dump(w, v.progress)
+	return err
+}
+
+// Copies a snapshot of the datastore to a new datastore file at path, then verifies it.
+func (v *Vedis) backup(path string) error {
+	dst := &Vedis{key: v.key, compressor: v.compressor, threshold: v.threshold}
+	if _, err := dst.OpenFile(path); err != nil {
+		return err
+	}
+	// the dump stream of the snapshot is only kept for its checksum
+	out := newDumpWriter(io.Discard)
+	err := v.snapshot(v.progress, func(key keyInfo, value string, entries []entry) error {
+		out.key(key, value, entries)
+		if key.Type == TypeString {
+			return dst.store(key.Name, value)
+		}
+		return dst.replace(key.Type, key.Name, entries)
+	})
+	if err != nil {
+		dst.Close()
+		return err
+	}
+	if _, err := dst.Close(); err != nil {
+		return err
+	}
+	want, err := out.close()
+	if err != nil {
+		return err
+	}
+
+	dst = &Vedis{key: v.key, compressor: v.compressor, threshold: v.threshold}
+	if _, err := dst.OpenReadOnly(path); err != nil {
+		return err
+	}
+	defer dst.Close()
+	if got, err := dst.dump(io.Discard, nil); err != nil {
+		return err
+	} else if got != want {
+		return ErrBackupMismatch
+	}
+	return nil
+}
+
+// Keys of the datastore being read by snapshot, with the values of the keys written before they were read.
+type snapshot struct {
+	v    *Vedis
+	keys []keyInfo
+	next int
+	// indexes in keys of every name
+	index  map[string][]int
+	copies map[int]snapshotValue
+}
+
+type snapshotValue struct {
+	value   string
+	entries []entry
+}
+
+// Calls fn with every key of the datastore and its value, a string or entries, as they were when snapshot was called.
+// progress is called as documented by OnBackupProgress. Refuses to read uncommitted changes, which may still be rolled back.
+func (v *Vedis) snapshot(progress func(done, total int), fn func(key keyInfo, value string, entries []entry) error) error {
+	if v.snap != nil {
+		return errors.New("a backup is already running")
+	}
+	if v.inTx() {
+		return errors.New("can not back up inside a transaction")
+	}
+	keys, err := v.keys()
+	if err != nil {
+		return err
+	}
+	s := &snapshot{v: v, keys: keys, index: make(map[string][]int), copies: make(map[int]snapshotValue)}
+	for i, key := range keys {
+		s.index[key.Name] = append(s.index[key.Name], i)
+	}
+	v.snap = s
+	defer func() { v.snap = nil }()
+	for i, key := range keys {
+		value, entries, err := s.read(i)
+		if err != nil {
+			return err
+		}
+		s.next = i + 1
+		if progress != nil {
+			progress(i, len(keys))
+		}
+		if err := fn(key, value, entries); err != nil {
+			return err
+		}
+	}
+	v.snap = nil
+	if progress != nil {
+		progress(len(keys), len(keys))
+	}
+	return nil
+}
+
+func (s *snapshot) read(i int) (string, []entry, error) {
+	if c, ok := s.copies[i]; ok {
+		delete(s.copies, i)
+		return c.value, c.entries, nil
+	}
+	key := s.keys[i]
+	if key.Type == TypeString {
+		value, _, err := s.v.fetch(key.Name)
+		return value, nil, err
+	}
+	entries, err := s.v.entries(key.Type, key.Name)
+	return "", entries, err
+}
+
+// Copies in memory the values of names not read yet, before they are first written.
+func (s *snapshot) copy(names []string) error {
+	for _, name := range names {
+		for _, i := range s.index[name] {
+			if _, ok := s.copies[i]; ok || i < s.next {
+				continue
+			}
+			value, entries, err := s.read(i)
+			if err != nil {
+				return err
+			}
+			s.copies[i] = snapshotValue{value, entries}
+		}
+	}
+	return nil
+}
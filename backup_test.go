@@ -0,0 +1,106 @@
+package vedis
+
+import (
+	"bytes"
+	"path/filepath"
+)
+
+func (suite *VedisTestSuite) TestBackup() {
+	suite.populate(suite.store)
+	var progress [][2]int
+	suite.store.OnBackupProgress(func(done, total int) { progress = append(progress, [2]int{done, total}) })
+
+	path := filepath.Join(suite.T().TempDir(), "backup.db")
+	if err := suite.store.Backup(path); err != nil {
+		suite.Fail(err.Error())
+	}
+	suite.Equal([][2]int{{0, 5}, {1, 5}, {2, 5}, {3, 5}, {4, 5}, {5, 5}}, progress)
+	suite.NoFileExists(path + ".backup")
+	backup := New()
+	backup.OpenFile(path)
+	suite.assertPopulated(backup)
+	backup.Close()
+
+	// the source is still writable
+	suite.store.Set("name", "Jane")
+	var buffer bytes.Buffer
+	if err := suite.store.BackupTo(&buffer); err != nil {
+		suite.Fail(err.Error())
+	}
+	restored := New()
+	restored.Open()
+	defer restored.Close()
+	if err := restored.Restore(&buffer); err != nil {
+		suite.Fail(err.Error())
+	}
+	if value, err := restored.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("Jane", value)
+	}
+
+	// writes during the backup are left out of it
+	suite.store.Set("name", "John")
+	suite.store.OnBackupProgress(func(done, total int) {
+		if done == 1 {
+			suite.store.Set("name", "Jim")
+			suite.store.Exec("HSET config timeout 100")
+			suite.store.Exec("SADD colors black; LPOP queue")
+			suite.store.Set("extra", "value")
+			// only the written keys not read yet are copied in memory
+			copied := 0
+			for _, name := range []string{"name", "config", "colors", "queue"} {
+				for _, i := range suite.store.snap.index[name] {
+					if i >= suite.store.snap.next {
+						copied++
+					}
+				}
+			}
+			suite.Len(suite.store.snap.copies, copied)
+		}
+	})
+	other := filepath.Join(suite.T().TempDir(), "other.db")
+	if err := suite.store.Backup(other); err != nil {
+		suite.Fail(err.Error())
+	}
+	suite.store.OnBackupProgress(nil)
+	backup = New()
+	backup.OpenFile(other)
+	suite.assertPopulated(backup)
+	if exists, err := backup.Exists("extra"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.False(exists)
+	}
+	backup.Close()
+	if value, err := suite.store.Get("extra"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("value", value)
+	}
+	suite.store.OnBackupProgress(func(done, total int) {
+		if done == 1 {
+			suite.store.Set("name", "Joe")
+		}
+	})
+	buffer.Reset()
+	if err := suite.store.BackupTo(&buffer); err != nil {
+		suite.Fail(err.Error())
+	}
+	suite.store.OnBackupProgress(nil)
+	restored = New()
+	restored.Open()
+	defer restored.Close()
+	if err := restored.Restore(&buffer); err != nil {
+		suite.Fail(err.Error())
+	}
+	if value, err := restored.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("Jim", value)
+	}
+
+	suite.store.Begin()
+	suite.Error(suite.store.Backup(other))
+	suite.store.Rollback()
+}
@@ -0,0 +1,331 @@
+package vedis
+
+// #include "vedis_extra.h"
+import "C"
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"runtime/cgo"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// Kinds of the problems reported by Check.
+const (
+	// A bucket page of the file can not be read or is malformed, its records are lost.
+	ProblemPage = "corrupt page"
+	// A record can not be read back, or its value can not be decompressed.
+	ProblemRecord = "corrupt record"
+	// A hash, set or list entry is malformed.
+	ProblemEntry = "corrupt entry"
+	// A hash, set or list entry belongs to no hash, set or list.
+	ProblemOrphan = "orphaned entry"
+	// A hash, set or list does not hold the number of entries recorded in its header.
+	// Vedis loops forever loading a hash, set or list missing some of its entries.
+	ProblemTable = "inconsistent table"
+)
+
+// Problem found by Check.
+type Problem struct {
+	Kind string
+	// Page holding the problem, 0 when it is not known.
+	Page int64
+	// Type and name of the key concerned, empty when they are not known.
+	Type    string
+	Key     string
+	Message string
+}
+
+func (p Problem) String() string {
+	s := p.Kind
+	if p.Page > 0 {
+		s += fmt.Sprintf(" on page %d", p.Page)
+	}
+	if p.Type != "" || p.Key != "" {
+		s += ","
+	}
+	if p.Type != "" {
+		s += " " + p.Type
+	}
+	if p.Key != "" {
+		s += fmt.Sprintf(" %q", p.Key)
+	}
+	return s + ": " + p.Message
+}
+
+// CheckReport is the result of Check.
+type CheckReport struct {
+	// Bucket pages and records walked.
+	Pages   int
+	Records int
+	// Readable keys, each hash, set or list counting once.
+	Keys     int
+	Problems []Problem
+}
+
+// Healthy reports whether Check found no problem.
+func (r *CheckReport) Healthy() bool {
+	return len(r.Problems) == 0
+}
+
+// Check walks every page and record of the datastore file, verifying their structure, and reports
+// corrupt pages and records, orphaned hash, set and list entries and inconsistent hashes, sets and lists.
+// Unlike the commands, it never loads a hash, set or list, so it is safe on damaged files,
+// which are best opened with OpenReadOnly. It never writes either: the hashes, sets and lists changed
+// since the last commit are checked against the headers Vedis keeps in memory until then.
+// The error is only set when the file can not be walked at all.
+func (v *Vedis) Check() (*CheckReport, error) {
+	c, err := v.check(false)
+	if err != nil {
+		return nil, err
+	}
+	return &c.report, nil
+}
+
+// Salvage copies every readable key of the datastore to a new datastore file at dstPath,
+// encrypted and compressed like the datastore itself, and returns the number of keys copied.
+// Hashes, sets and lists keep the entries found, orphaned entries are dropped.
+func (v *Vedis) Salvage(dstPath string) (int, error) {
+	if _, err := os.Stat(dstPath); err == nil {
+		return 0, &os.PathError{Op: "salvage", Path: dstPath, Err: os.ErrExist}
+	}
+	c, err := v.check(true)
+	if err != nil {
+		return 0, err
+	}
+	dst := &Vedis{key: v.key, compressor: v.compressor, threshold: v.threshold}
+	if _, err := dst.OpenFile(dstPath); err != nil {
+		return 0, err
+	}
+	count := 0
+	for name, value := range c.strings {
+		if err := dst.store(name, value); err != nil {
+			dst.Close()
+			return count, err
+		}
+		count++
+	}
+	for id, t := range c.tables {
+		if len(t.entries) == 0 {
+			continue
+		}
+		entries := make([]entry, len(t.entries))
+		for i, e := range t.entries {
+			entries[i] = e.entry
+		}
+		if err := dst.replace(id.typ, id.name, entries); err != nil {
+			dst.Close()
+			return count, err
+		}
+		count++
+	}
+	if _, err := dst.Close(); err != nil {
+		return count, err
+	}
+	return count, nil
+}
+
+// Hash, set or list identified by its type and name.
+type tableID struct {
+	typ  string
+	name string
+}
+
+// Hash, set or list found in the records, with the number of entries claimed by its header.
+type checkedTable struct {
+	count   uint32
+	entries []checkedEntry
+}
+
+type checkedEntry struct {
+	table tableID
+	page  int64
+	id    uint32
+	entry entry
+}
+
+// Collects the records walked by vedis_extra_check, keeping the readable values when salvaging.
+type checker struct {
+	v       *Vedis
+	keep    bool
+	report  CheckReport
+	strings map[string]string
+	tables  map[tableID]*checkedTable
+	entries []checkedEntry
+}
+
+//export goCheckPage
+func goCheckPage(handle C.uintptr_t, page C.vedis_int64, status C.int) {
+	c := cgo.Handle(handle).Value().(*checker)
+	c.report.Pages++
+	if status != C.VEDIS_OK {
+		c.problem(Problem{Kind: ProblemPage, Page: int64(page), Message: fmt.Sprintf("bucket can not be loaded (%d)", status)})
+	}
+}
+
+//export goCheckRecord
+func goCheckRecord(handle C.uintptr_t, page C.vedis_int64, key unsafe.Pointer, keyLength C.int, data unsafe.Pointer, dataLength C.int, status C.int) {
+	c := cgo.Handle(handle).Value().(*checker)
+	name := C.GoStringN((*C.char)(key), keyLength)
+	var value string
+	if c.keep || c.v.compressor != nil || strings.HasPrefix(name, "vt") {
+		value = C.GoStringN((*C.char)(data), dataLength)
+	}
+	c.record(int64(page), name, value, int(status))
+}
+
+//export goCheckTable
+func goCheckTable(handle C.uintptr_t, kind C.int, name unsafe.Pointer, length C.int, entries C.uint) {
+	c := cgo.Handle(handle).Value().(*checker)
+	if typ, ok := tableType(byte('0' + kind)); ok {
+		// the header kept in memory replaces the one of the file, which is written on commit
+		c.tables[tableID{typ, C.GoStringN((*C.char)(name), length)}] = &checkedTable{count: uint32(entries)}
+	}
+}
+
+// Walks the datastore, then matches the hash, set and list entries with their headers.
+func (v *Vedis) check(keep bool) (*checker, error) {
+	c := &checker{v: v, keep: keep, strings: make(map[string]string), tables: make(map[tableID]*checkedTable)}
+	handle := cgo.NewHandle(c)
+	defer handle.Delete()
+	if status := C.vedis_extra_check(v.ptr, C.uintptr_t(handle)); status != C.VEDIS_OK {
+		return nil, newError(status, v.ptr)
+	}
+	c.match()
+	return c, nil
+}
+
+func (c *checker) problem(p Problem) {
+	c.report.Problems = append(c.report.Problems, p)
+}
+
+func (c *checker) record(page int64, name string, value string, status int) {
+	c.report.Records++
+	if status != C.VEDIS_OK {
+		c.problem(Problem{Kind: ProblemRecord, Page: page, Key: name, Message: fmt.Sprintf("record can not be read (%d)", status)})
+		return
+	}
+	if id, count, ok := tableHeader(name, value); ok {
+		c.tables[id] = &checkedTable{count: count}
+		return
+	}
+	if isTableEntry(name, value) {
+		if e, err := tableEntry(name, value); err != nil {
+			c.problem(Problem{Kind: ProblemEntry, Page: page, Message: err.Error()})
+		} else {
+			e.page = page
+			c.entries = append(c.entries, e)
+		}
+		return
+	}
+	value, err := c.v.unpack(value)
+	if err != nil {
+		c.problem(Problem{Kind: ProblemRecord, Page: page, Type: TypeString, Key: name, Message: err.Error()})
+		return
+	}
+	c.report.Keys++
+	if c.keep {
+		c.strings[name] = value
+	}
+}
+
+// Gives each entry to its hash, set or list, in insertion order, and compares their number with the header.
+func (c *checker) match() {
+	for _, e := range c.entries {
+		t, ok := c.tables[e.table]
+		if !ok {
+			c.problem(Problem{Kind: ProblemOrphan, Page: e.page, Type: e.table.typ, Key: e.table.name, Message: fmt.Sprintf("entry %d belongs to no %s", e.id, e.table.typ)})
+			continue
+		}
+		var err error
+		if e.entry, err = c.v.unpackEntry(e.table.typ, e.entry); err != nil {
+			c.problem(Problem{Kind: ProblemEntry, Page: e.page, Type: e.table.typ, Key: e.table.name, Message: fmt.Sprintf("entry %d: %s", e.id, err)})
+			continue
+		}
+		t.entries = append(t.entries, e)
+	}
+	ids := make([]tableID, 0, len(c.tables))
+	for id := range c.tables {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].typ != ids[j].typ {
+			return tableTypes[ids[i].typ] < tableTypes[ids[j].typ]
+		}
+		return ids[i].name < ids[j].name
+	})
+	for _, id := range ids {
+		t := c.tables[id]
+		sort.Slice(t.entries, func(i, j int) bool { return t.entries[i].id < t.entries[j].id })
+		if len(t.entries) != int(t.count) {
+			c.problem(Problem{Kind: ProblemTable, Type: id.typ, Key: id.name, Message: fmt.Sprintf("%d entries expected, %d found", t.count, len(t.entries))})
+		}
+		if len(t.entries) > 0 {
+			c.report.Keys++
+		}
+	}
+}
+
+// Record layout of the hashes, sets and lists, see vedisTableSerialize and vedisTableEntrySerialize.
+// A header is keyed "vt" type name, an entry "vt" name type id.
+const (
+	tableMagic      = 0xCA10
+	tableEntryMagic = 0xEF32
+	intEntry        = 1
+	blobEntry       = 2
+)
+
+func tableType(digit byte) (string, bool) {
+	for typ, value := range tableTypes {
+		if typ != TypeString && int(value) == int(digit-'0') {
+			return typ, true
+		}
+	}
+	return "", false
+}
+
+// Decodes a header record, the same way as vedis_extra_table_header.
+func tableHeader(name string, data string) (tableID, uint32, bool) {
+	if len(name) < 4 || !strings.HasPrefix(name, "vt") || len(data) != 2+4+4 || binary.BigEndian.Uint16([]byte(data)) != tableMagic {
+		return tableID{}, 0, false
+	}
+	typ, ok := tableType(name[2])
+	if !ok {
+		return tableID{}, 0, false
+	}
+	return tableID{typ, name[3:]}, binary.BigEndian.Uint32([]byte(data[6:])), true
+}
+
+// Tells entry records, the same way as vedis_extra_table_entry.
+func isTableEntry(name string, data string) bool {
+	return len(name) >= 3 && strings.HasPrefix(name, "vt") && len(data) >= 2+4+1+4+4 && binary.BigEndian.Uint16([]byte(data)) == tableEntryMagic
+}
+
+// Decodes an entry record, the same way as vedisUnserializeEntry.
+// The entry holds its id, which tells the name of its hash, set or list apart from the type and id in the key.
+func tableEntry(name string, data string) (checkedEntry, error) {
+	raw := []byte(data)
+	id, kind := binary.BigEndian.Uint32(raw[2:]), raw[6]
+	keyLength, dataLength := binary.BigEndian.Uint32(raw[7:]), binary.BigEndian.Uint32(raw[11:])
+	payload := data[15:]
+	if (kind != intEntry && kind != blobEntry) || uint64(len(payload)) != uint64(keyLength)+uint64(dataLength) {
+		return checkedEntry{}, fmt.Errorf("malformed entry %q", name)
+	}
+	prefix, ok := strings.CutSuffix(name[2:], strconv.FormatUint(uint64(id), 10))
+	if !ok || len(prefix) < 2 {
+		return checkedEntry{}, fmt.Errorf("entry %q does not match its id %d", name, id)
+	}
+	typ, ok := tableType(prefix[len(prefix)-1])
+	if !ok {
+		return checkedEntry{}, fmt.Errorf("entry %q has no type", name)
+	}
+	e := checkedEntry{table: tableID{typ, prefix[:len(prefix)-1]}, id: id}
+	if kind == blobEntry {
+		e.entry.Key = payload[:keyLength]
+	}
+	e.entry.Data = payload[keyLength:]
+	return e, nil
+}
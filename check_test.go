@@ -0,0 +1,92 @@
+package vedis
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+)
+
+func (suite *VedisTestSuite) TestCheck() {
+	path := filepath.Join(suite.T().TempDir(), "test.db")
+	store := New()
+	store.OpenFile(path)
+	suite.populate(store)
+	if report, err := store.Check(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(report.Healthy())
+		suite.Equal(5, report.Keys)
+	}
+	store.Close()
+
+	// drop the header of a hash and an entry of a set
+	store = New()
+	store.OpenFile(path)
+	store.delete("vt1config")
+	store.delete("vtcolors20")
+	store.Close()
+	store = New()
+	store.OpenReadOnly(path)
+	if report, err := store.Check(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(4, report.Keys)
+		suite.Len(report.Problems, 3)
+		suite.Equal(Problem{ProblemOrphan, 2, TypeHash, "config", "entry 0 belongs to no hash"}, report.Problems[0])
+		suite.Equal(`inconsistent table, set "colors": 3 entries expected, 2 found`, report.Problems[2].String())
+	}
+	salvaged := path + ".salvaged"
+	if count, err := store.Salvage(salvaged); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(4, count)
+	}
+	_, err := store.Salvage(salvaged)
+	suite.ErrorIs(err, os.ErrExist)
+	store.Close()
+
+	store = New()
+	store.OpenFile(salvaged)
+	if report, err := store.Check(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(report.Healthy())
+	}
+	if members, err := store.Exec("SMEMBERS colors"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]interface{}{"green", "blue"}, members)
+	}
+	if value, err := store.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("John", value)
+	}
+	store.Close()
+
+	// damage a key, then the page holding the records
+	raw, _ := os.ReadFile(path)
+	at := bytes.Index(raw, []byte("binary"))
+	raw[at] = 'B'
+	os.WriteFile(path, raw, 0644)
+	store = New()
+	store.OpenReadOnly(path)
+	if report, err := store.Check(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(Problem{ProblemRecord, 2, "", "Binary", "record can not be read (-24)"}, report.Problems[0])
+	}
+	store.Close()
+
+	raw[at/DefaultPageSize*DefaultPageSize] = 0xff
+	os.WriteFile(path, raw, 0644)
+	store = New()
+	store.OpenReadOnly(path)
+	if report, err := store.Check(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Zero(report.Keys)
+		suite.Equal([]Problem{{ProblemPage, 2, "", "", "bucket can not be loaded (-24)"}}, report.Problems)
+	}
+	store.Close()
+}
@@ -0,0 +1,67 @@
+// Command vedis-check verifies the integrity of a Vedis datastore file.
+//
+// Usage:
+//
+//	vedis-check [-repair file] path
+//
+// Every page and record is walked and the problems found are printed, the exit status is 1 if there is any.
+// With -repair, the readable keys are salvaged into a new datastore file.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-zero/go-vedis"
+)
+
+func main() {
+	repair := flag.String("repair", "", "salvage the readable keys into a new datastore `file`")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: vedis-check [-repair file] path\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	healthy, err := check(flag.Arg(0), *repair)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "vedis-check: %s\n", strings.TrimSpace(err.Error()))
+		os.Exit(1)
+	}
+	if !healthy {
+		os.Exit(1)
+	}
+}
+
+func check(path string, repair string) (bool, error) {
+	// read-only, so that the file being checked is never written
+	store := vedis.New()
+	if _, err := store.OpenReadOnly(path); err != nil {
+		return false, err
+	}
+	defer store.Close()
+
+	report, err := store.Check()
+	if err != nil {
+		return false, err
+	}
+	for _, problem := range report.Problems {
+		fmt.Println(problem)
+	}
+	fmt.Printf("%d pages, %d records, %d keys, %d problems\n", report.Pages, report.Records, report.Keys, len(report.Problems))
+
+	if repair != "" {
+		count, err := store.Salvage(repair)
+		if err != nil {
+			return false, err
+		}
+		fmt.Printf("%d keys salvaged to %s\n", count, repair)
+	}
+	return report.Healthy(), nil
+}
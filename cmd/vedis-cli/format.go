@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Prints a command result according to its type, nested arrays are indented.
+func format(w io.Writer, value interface{}, indent string) {
+	switch value := value.(type) {
+	case nil:
+		fmt.Fprintln(w, "(nil)")
+	case int64:
+		fmt.Fprintf(w, "(integer) %d\n", value)
+	case float64:
+		fmt.Fprintf(w, "(float) %s\n", strconv.FormatFloat(value, 'g', -1, 64))
+	case bool:
+		fmt.Fprintf(w, "(bool) %t\n", value)
+	case string:
+		fmt.Fprintln(w, strconv.Quote(value))
+	case []interface{}:
+		if len(value) == 0 {
+			fmt.Fprintln(w, "(empty array)")
+			return
+		}
+		width := len(strconv.Itoa(len(value)))
+		for i, elem := range value {
+			if i > 0 {
+				fmt.Fprint(w, indent)
+			}
+			label := fmt.Sprintf("%*d) ", width, i+1)
+			fmt.Fprint(w, label)
+			format(w, elem, indent+strings.Repeat(" ", len(label)))
+		}
+	default:
+		fmt.Fprintf(w, "%v\n", value)
+	}
+}
+
+// Accumulates input lines until a complete command is read.
+// A command continues on the next line when the line ends with a backslash or a quoted string is left open.
+type statement struct {
+	buffer strings.Builder
+}
+
+// Adds a line to the statement, returning the whole command once it is complete.
+func (s *statement) add(line string) (string, bool) {
+	if s.buffer.Len() > 0 {
+		if quoted(s.buffer.String()) {
+			s.buffer.WriteByte('\n')
+		} else {
+			s.buffer.WriteByte(' ')
+		}
+	}
+	if !quoted(s.buffer.String()+line) && strings.HasSuffix(line, `\`) {
+		s.buffer.WriteString(strings.TrimSuffix(line, `\`))
+		return "", false
+	}
+	s.buffer.WriteString(line)
+	if quoted(s.buffer.String()) {
+		return "", false
+	}
+	command := s.buffer.String()
+	s.buffer.Reset()
+	return command, true
+}
+
+// Reports whether a command is waiting for more lines.
+func (s *statement) pending() bool {
+	return s.buffer.Len() > 0
+}
+
+// Reports whether text ends inside a quoted string.
+func quoted(text string) bool {
+	var quote rune
+	escaped := false
+	for _, c := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && quote != 0:
+			escaped = true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		}
+	}
+	return quote != 0
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	var out bytes.Buffer
+	format(&out, []interface{}{"a", int64(1), nil, []interface{}{true, 1.5}, []interface{}{}}, "")
+	assert.Equal(t, `1) "a"
+2) (integer) 1
+3) (nil)
+4) 1) (bool) true
+   2) (float) 1.5
+5) (empty array)
+`, out.String())
+}
+
+func TestStatement(t *testing.T) {
+	var stmt statement
+
+	command, ok := stmt.add("SET name John")
+	assert.True(t, ok)
+	assert.Equal(t, "SET name John", command)
+
+	_, ok = stmt.add(`MGET a \`)
+	assert.False(t, ok)
+	command, ok = stmt.add("b")
+	assert.True(t, ok)
+	assert.Equal(t, "MGET a  b", command)
+
+	_, ok = stmt.add(`SET message "hello`)
+	assert.False(t, ok)
+	assert.True(t, stmt.pending())
+	command, ok = stmt.add(`world"`)
+	assert.True(t, ok)
+	assert.Equal(t, "SET message \"hello\nworld\"", command)
+	assert.False(t, stmt.pending())
+}
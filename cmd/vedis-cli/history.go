@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"os"
+)
+
+// Number of history entries kept between sessions.
+const historySize = 1000
+
+// History of entered lines, persisted to a file so it survives between sessions.
+type history struct {
+	path    string
+	entries []string
+}
+
+func openHistory(path string) *history {
+	h := &history{path: path}
+	if f, err := os.Open(path); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			h.entries = append(h.entries, scanner.Text())
+		}
+		f.Close()
+	}
+	if len(h.entries) > historySize {
+		h.entries = h.entries[len(h.entries)-historySize:]
+	}
+	return h
+}
+
+// Add records a new entry, appending it to the history file.
+func (h *history) Add(entry string) {
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == entry {
+		return
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > historySize {
+		h.entries = h.entries[1:]
+	}
+	if f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600); err == nil {
+		f.WriteString(entry + "\n")
+		f.Close()
+	}
+}
+
+// Len returns the number of entries.
+func (h *history) Len() int {
+	return len(h.entries)
+}
+
+// At returns an entry, 0 being the most recent one.
+func (h *history) At(idx int) string {
+	return h.entries[len(h.entries)-1-idx]
+}
@@ -0,0 +1,201 @@
+// Command vedis-cli is an interactive shell for Vedis datastores.
+//
+// Usage:
+//
+//	vedis-cli [-f file] [-timing] [path]
+//
+// The datastore at path is opened (or created), an in-memory datastore is used when path is omitted.
+// When standard input is a terminal an interactive shell with line editing and history is started,
+// otherwise commands are read from standard input (or from the file given with -f), one per line.
+//
+// A line ending with a backslash, or with an unterminated quoted string, continues on the next line.
+// Besides Vedis commands the shell understands:
+//
+//	\timing  toggle the display of the time spent by each command
+//	\help    show this help
+//	\quit    exit the shell
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-zero/go-vedis"
+	"golang.org/x/term"
+)
+
+const (
+	prompt       = "vedis> "
+	continuation = "  ...> "
+	help         = `Type a Vedis command (i.e. SET key value) or one of:
+  \timing  toggle the display of the time spent by each command
+  \help    show this help
+  \quit    exit the shell
+See http://vedis.symisc.net/commands.html for the list of commands.
+`
+)
+
+type shell struct {
+	store  *vedis.Vedis
+	out    io.Writer
+	timing bool
+	quit   bool
+}
+
+func main() {
+	file := flag.String("f", "", "read commands from `file` instead of standard input")
+	timing := flag.Bool("timing", false, "display the time spent by each command")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: vedis-cli [-f file] [-timing] [path]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	path := ":mem:"
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	} else if flag.NArg() == 1 {
+		path = flag.Arg(0)
+	}
+
+	store := vedis.New()
+	if _, err := store.OpenFile(path); err != nil {
+		fmt.Fprintf(os.Stderr, "vedis-cli: %s: %v\n", path, err)
+		os.Exit(1)
+	}
+
+	sh := &shell{store: store, out: os.Stdout, timing: *timing}
+
+	var err error
+	switch {
+	case *file != "":
+		var f *os.File
+		if f, err = os.Open(*file); err == nil {
+			err = sh.batch(f)
+			f.Close()
+		}
+	case term.IsTerminal(int(os.Stdin.Fd())):
+		err = sh.interactive()
+	default:
+		err = sh.batch(os.Stdin)
+	}
+	// Close commits the writes, which are lost if it fails
+	_, closeErr := store.Close()
+	if err := errors.Join(err, closeErr); err != nil {
+		fmt.Fprintf(os.Stderr, "vedis-cli: %s\n", strings.TrimSpace(err.Error()))
+		os.Exit(1)
+	}
+}
+
+// Runs the commands read from r, stopping at the first failing command.
+func (sh *shell) batch(r io.Reader) error {
+	var stmt statement
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() && !sh.quit {
+		if command, ok := stmt.add(scanner.Text()); ok {
+			if err := sh.run(command); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if stmt.pending() {
+		return fmt.Errorf("unexpected end of input")
+	}
+	return nil
+}
+
+// Runs an interactive session with line editing and history.
+func (sh *shell) interactive() error {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, prompt)
+	if width, height, err := term.GetSize(fd); err == nil {
+		t.SetSize(width, height)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		t.History = openHistory(filepath.Join(home, ".vedis_history"))
+	}
+	sh.out = t
+
+	var stmt statement
+	for !sh.quit {
+		line, err := t.ReadLine()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		command, ok := stmt.add(line)
+		if !ok {
+			t.SetPrompt(continuation)
+			continue
+		}
+		t.SetPrompt(prompt)
+		if err := sh.run(command); err != nil {
+			fmt.Fprintf(t, "(error) %s\n", strings.TrimSpace(err.Error()))
+		}
+	}
+	return nil
+}
+
+// Runs a single shell or Vedis command and prints its result.
+func (sh *shell) run(command string) error {
+	command = strings.TrimSpace(command)
+	switch {
+	case command == "":
+		return nil
+	case strings.HasPrefix(command, `\`):
+		return sh.meta(command)
+	}
+
+	start := time.Now()
+	value, err := sh.store.Exec(command)
+	elapsed := time.Since(start)
+	if err != nil {
+		return err
+	}
+	format(sh.out, value, "")
+	if sh.timing {
+		fmt.Fprintf(sh.out, "Time: %v\n", elapsed)
+	}
+	return nil
+}
+
+func (sh *shell) meta(command string) error {
+	switch strings.Fields(command)[0] {
+	case `\timing`:
+		sh.timing = !sh.timing
+		if sh.timing {
+			fmt.Fprintln(sh.out, "Timing is on.")
+		} else {
+			fmt.Fprintln(sh.out, "Timing is off.")
+		}
+	case `\help`, `\h`, `\?`:
+		fmt.Fprint(sh.out, help)
+	case `\quit`, `\q`:
+		sh.quit = true
+	default:
+		return fmt.Errorf("unknown shell command %s, try \\help", command)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+// Command vedis-dump writes every key of a Vedis datastore to a portable dump stream.
+//
+// Usage:
+//
+//	vedis-dump [-o file] path
+//
+// The dump is written to standard output unless -o is given, and can be loaded back with vedis-restore.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-zero/go-vedis"
+)
+
+func main() {
+	output := flag.String("o", "", "write the dump to `file` instead of standard output")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: vedis-dump [-o file] path\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := dump(flag.Arg(0), *output); err != nil {
+		fmt.Fprintf(os.Stderr, "vedis-dump: %s\n", strings.TrimSpace(err.Error()))
+		os.Exit(1)
+	}
+}
+
+func dump(path string, output string) error {
+	store := vedis.New()
+	if _, err := store.OpenFile(path); err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if output == "" {
+		return store.Dump(os.Stdout)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := store.Dump(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
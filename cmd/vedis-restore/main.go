@@ -0,0 +1,60 @@
+// Command vedis-restore loads a dump written by vedis-dump into a Vedis datastore.
+//
+// Usage:
+//
+//	vedis-restore [-i file] path
+//
+// The dump is read from standard input unless -i is given.
+// The datastore at path is created if needed, existing keys are replaced by the dumped ones.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-zero/go-vedis"
+)
+
+func main() {
+	input := flag.String("i", "", "read the dump from `file` instead of standard input")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: vedis-restore [-i file] path\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := restore(flag.Arg(0), *input); err != nil {
+		fmt.Fprintf(os.Stderr, "vedis-restore: %s\n", strings.TrimSpace(err.Error()))
+		os.Exit(1)
+	}
+}
+
+func restore(path string, input string) error {
+	var r io.Reader = os.Stdin
+	if input != "" {
+		f, err := os.Open(input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	store := vedis.New()
+	if _, err := store.OpenFile(path); err != nil {
+		return err
+	}
+	if err := store.Restore(r); err != nil {
+		store.Close()
+		return err
+	}
+	_, err := store.Close()
+	return err
+}
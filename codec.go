@@ -0,0 +1,99 @@
+package vedis
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Codec converts the values handled by the typed wrappers to and from the bytes stored in the datastore.
+type Codec[T any] interface {
+	Encode(value T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// JSONCodec encodes values with encoding/json.
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Encode(value T) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (JSONCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+// GobCodec encodes values with encoding/gob.
+// Each value is encoded on its own, so its type information is stored along with it.
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Encode(value T) ([]byte, error) {
+	var buffer bytes.Buffer
+	err := gob.NewEncoder(&buffer).Encode(value)
+	return buffer.Bytes(), err
+}
+
+func (GobCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value)
+	return value, err
+}
+
+// BinaryCodec encodes values with their own binary format:
+// encoding.BinaryMarshaler and encoding.BinaryUnmarshaler, or the Marshal and Unmarshal methods of protobuf messages.
+// T (or a pointer to T for decoding) must implement one of them.
+type BinaryCodec[T any] struct{}
+
+func (BinaryCodec[T]) Encode(value T) ([]byte, error) {
+	switch m := any(value).(type) {
+	case encoding.BinaryMarshaler:
+		return m.MarshalBinary()
+	case interface{ Marshal() ([]byte, error) }:
+		return m.Marshal()
+	}
+	return nil, fmt.Errorf("%T does not implement a binary marshaler", value)
+}
+
+func (BinaryCodec[T]) Decode(data []byte) (T, error) {
+	var value T
+	target := any(&value)
+	// protobuf messages are handled through pointers, allocate the message itself
+	if t := reflect.TypeFor[T](); t.Kind() == reflect.Pointer {
+		value = reflect.New(t.Elem()).Interface().(T)
+		target = value
+	}
+	switch u := target.(type) {
+	case encoding.BinaryUnmarshaler:
+		return value, u.UnmarshalBinary(data)
+	case interface{ Unmarshal([]byte) error }:
+		return value, u.Unmarshal(data)
+	}
+	return value, fmt.Errorf("%T does not implement a binary unmarshaler", target)
+}
+
+// RawCodec stores byte slices as they are.
+type RawCodec struct{}
+
+func (RawCodec) Encode(value []byte) ([]byte, error) {
+	return value, nil
+}
+
+func (RawCodec) Decode(data []byte) ([]byte, error) {
+	return data, nil
+}
+
+// StringCodec stores strings as they are.
+type StringCodec struct{}
+
+func (StringCodec) Encode(value string) ([]byte, error) {
+	return []byte(value), nil
+}
+
+func (StringCodec) Decode(data []byte) (string, error) {
+	return string(data), nil
+}
@@ -0,0 +1,117 @@
+package vedis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Compressor compresses the values of a datastore, see Compress.
+type Compressor interface {
+	// ID identifies the compression format in the header of compressed values, it must never change.
+	ID() byte
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// GzipCompressor compresses values with compress/gzip.
+type GzipCompressor struct {
+	// Level is a compress/gzip level, 0 meaning gzip.DefaultCompression.
+	Level int
+}
+
+func (GzipCompressor) ID() byte {
+	return 1
+}
+
+func (c GzipCompressor) Compress(data []byte) ([]byte, error) {
+	level := c.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	var buffer bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buffer, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func (GzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Header bytes of the values stored while a compressor is set.
+// Neither can start UTF-8 text, so values stored before remain readable as they are.
+const (
+	// followed by the ID of the compressor and the compressed value
+	compressedValue = 0xFE
+	// followed by a value which starts with one of the header bytes itself
+	escapedValue = 0xFF
+)
+
+// Compress the values longer than threshold bytes with c, values which do not shrink being stored as they are.
+// Set, SetNX, MSet, MSetNX, GetSet, Append, HSet, HSetNX, HMSet and the typed wrappers compress the values they write,
+// Get, MGet, GetSet, StrLen, HGet, HMGet, HStrLen, HVals, HGetAll and the typed wrappers decompress the values they read,
+// other commands see the stored bytes.
+// A nil compressor stops compressing new values, but also decompressing the ones already stored.
+func (v *Vedis) Compress(c Compressor, threshold int) error {
+	if threshold < 0 {
+		return fmt.Errorf("Compress: invalid threshold %d", threshold)
+	}
+	v.compressor, v.threshold = c, threshold
+	return nil
+}
+
+// Returns value as it must be stored.
+func (v *Vedis) pack(value string) (string, error) {
+	if v.compressor == nil {
+		return value, nil
+	}
+	if len(value) > v.threshold {
+		data, err := v.compressor.Compress([]byte(value))
+		if err != nil {
+			return "", fmt.Errorf("compress: %w", err)
+		}
+		if len(data)+2 < len(value) {
+			return string([]byte{compressedValue, v.compressor.ID()}) + string(data), nil
+		}
+	}
+	if len(value) > 0 && (value[0] == compressedValue || value[0] == escapedValue) {
+		return string([]byte{escapedValue}) + value, nil
+	}
+	return value, nil
+}
+
+// Returns the value stored as data.
+func (v *Vedis) unpack(data string) (string, error) {
+	if v.compressor == nil || len(data) == 0 {
+		return data, nil
+	}
+	switch data[0] {
+	case escapedValue:
+		return data[1:], nil
+	case compressedValue:
+		if len(data) < 2 || data[1] != v.compressor.ID() {
+			return "", fmt.Errorf("decompress: unknown compression format")
+		}
+		value, err := v.compressor.Decompress([]byte(data[2:]))
+		if err != nil {
+			return "", fmt.Errorf("decompress: %w", err)
+		}
+		return string(value), nil
+	}
+	return data, nil
+}
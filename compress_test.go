@@ -0,0 +1,117 @@
+package vedis
+
+import "strings"
+
+func (suite *VedisTestSuite) TestCompress() {
+	document := `{"name": "John", "tags": [` + strings.Repeat(`"vedis", `, 200) + `"go"]}`
+	before := strings.Repeat("vedis ", 200)
+	suite.store.Set("before", before)
+	suite.Error(suite.store.Compress(GzipCompressor{}, -1))
+	if err := suite.store.Compress(GzipCompressor{}, 64); err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+
+	suite.store.Set("document", document)
+	suite.store.Set("small", "John")
+	suite.store.Set("escaped", "\xffJohn")
+	if n, err := suite.store.StrLen("document"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(len(document), n)
+	}
+	if n, err := suite.store.StrLen("small"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(4, n)
+	}
+
+	if values, err := suite.store.MGet("document", "small", "escaped", "before", "missing"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]string{document, "John", "\xffJohn", before, ""}, values)
+	}
+
+	if n, err := suite.store.Append("document", "tail"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(len(document)+4, n)
+	}
+	if old, err := suite.store.GetSet("document", document); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(document+"tail", old)
+	}
+	if n, err := suite.store.Append("new", document); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(len(document), n)
+	}
+	if ok, err := suite.store.SetNX("document", "John"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.False(ok)
+	}
+	if ok, err := suite.store.SetNX("other", document); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+	if ok, err := suite.store.MSetNX("document", "John", "third", document); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+	if values, err := suite.store.MGet("document", "new", "other", "third"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]string{document, document, document, document}, values)
+	}
+	if n, err := suite.store.Exec("STRLEN other"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		// stored compressed
+		suite.Less(n, int64(len(document)/4))
+	}
+
+	suite.store.HSet("users", "john", document)
+	suite.store.HMSet("users", "jane", "Jane", "jim", document)
+	if value, err := suite.store.HGet("users", "john"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(document, value)
+	}
+	if values, err := suite.store.HGetAll("users"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.ElementsMatch([]string{"john", document, "jane", "Jane", "jim", document}, values)
+	}
+	if n, err := suite.store.HStrLen("users", "jim"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(len(document), n)
+	}
+	if ok, err := suite.store.HSetNX("users", "jim", "Jim"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.False(ok)
+	}
+	if ok, err := suite.store.HSetNX("users", "joe", document); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+	if value, err := suite.store.HGet("users", "joe"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(document, value)
+	}
+
+	list := NewList(suite.store, "documents", StringCodec{})
+	list.Push(document)
+	if items, err := list.Items(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]string{document}, items)
+	}
+}
@@ -0,0 +1,103 @@
+package driver
+
+import (
+	sqldriver "database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Walks the query calling fn for each ? placeholder outside of a quoted string.
+// Quoted strings follow the Vedis lexer: a quote preceded by a backslash does not close the string.
+func scan(query string, fn func(i int)) {
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote && query[i-1] != '\\' {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '?':
+			fn(i)
+		}
+	}
+}
+
+func placeholders(query string) int {
+	count := 0
+	scan(query, func(int) { count++ })
+	return count
+}
+
+// Replaces the placeholders of the query with the quoted arguments.
+func bind(query string, args []sqldriver.NamedValue) (string, error) {
+	var out strings.Builder
+	var err error
+	last, n := 0, 0
+	scan(query, func(i int) {
+		if err != nil {
+			return
+		}
+		if n >= len(args) {
+			err = fmt.Errorf("driver: missing argument for placeholder %d", n+1)
+			return
+		}
+		var literal string
+		if literal, err = quote(args[n]); err == nil {
+			out.WriteString(query[last:i])
+			out.WriteString(literal)
+			last, n = i+1, n+1
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+	if n != len(args) {
+		return "", fmt.Errorf("driver: expected %d arguments, got %d", n, len(args))
+	}
+	out.WriteString(query[last:])
+	return out.String(), nil
+}
+
+// Quotes an argument so the Vedis lexer reads it back as a single token holding the exact value.
+// Values that can not be represented that way are rejected instead of being altered.
+func quote(arg sqldriver.NamedValue) (string, error) {
+	if arg.Name != "" {
+		return "", fmt.Errorf("driver: named argument %q is not supported", arg.Name)
+	}
+	var s string
+	switch value := arg.Value.(type) {
+	case nil:
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64), nil
+	case bool:
+		s = strconv.FormatBool(value)
+	case []byte:
+		s = string(value)
+	case string:
+		s = value
+	case time.Time:
+		s = value.Format(time.RFC3339Nano)
+	default:
+		return "", fmt.Errorf("driver: unsupported argument type %T", value)
+	}
+	if strings.IndexByte(s, 0) >= 0 {
+		return "", fmt.Errorf("driver: argument %d holds a NUL byte", arg.Ordinal)
+	}
+	if strings.HasSuffix(s, `\`) {
+		return "", fmt.Errorf("driver: argument %d ends with a backslash", arg.Ordinal)
+	}
+	switch {
+	case !strings.Contains(s, `"`):
+		return `"` + s + `"`, nil
+	case !strings.Contains(s, `'`):
+		return `'` + s + `'`, nil
+	}
+	return "", fmt.Errorf("driver: argument %d holds both single and double quotes", arg.Ordinal)
+}
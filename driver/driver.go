@@ -0,0 +1,247 @@
+// Package driver registers a "vedis" driver for database/sql.
+//
+// The data source name is the path of the datastore, ":mem:" (or an empty name) opening an in-memory datastore.
+// Queries are raw Vedis commands where ? placeholders are replaced by the quoted arguments:
+//
+//	db, _ := sql.Open("vedis", "data.db")
+//	db.Exec("HSET ? ? ?", "config", "url", "github.com")
+//	db.QueryRow("HGET ? ?", "config", "url").Scan(&url)
+//
+// Query results have a single "value" column, with a row for each element of array results.
+//
+// Vedis is not safe for concurrent use, so every connection opened on the same data source shares the same
+// datastore and commands are run one at a time. A transaction holds the datastore until it is committed or
+// rolled back: running commands outside of the transaction, from the goroutine owning it, would block forever.
+package driver
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"errors"
+	"sync"
+
+	"github.com/go-zero/go-vedis"
+)
+
+func init() {
+	sql.Register("vedis", &Driver{})
+}
+
+// Driver implements database/sql/driver.Driver for Vedis datastores.
+type Driver struct{}
+
+// Open returns a connection to the datastore named by dsn.
+func (d *Driver) Open(dsn string) (sqldriver.Conn, error) {
+	s, err := acquire(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{store: s}, nil
+}
+
+// A datastore shared by the connections of a data source.
+type store struct {
+	sync.Mutex
+	dsn  string
+	v    *vedis.Vedis
+	refs int
+}
+
+var stores = struct {
+	sync.Mutex
+	m map[string]*store
+}{m: map[string]*store{}}
+
+func acquire(dsn string) (*store, error) {
+	if dsn == "" {
+		dsn = ":mem:"
+	}
+	stores.Lock()
+	defer stores.Unlock()
+	if s, ok := stores.m[dsn]; ok {
+		s.refs++
+		return s, nil
+	}
+	v := vedis.New()
+	if _, err := v.OpenFile(dsn); err != nil {
+		return nil, err
+	}
+	s := &store{dsn: dsn, v: v, refs: 1}
+	stores.m[dsn] = s
+	return s, nil
+}
+
+// Closes the datastore once its last connection is closed.
+func (s *store) release() error {
+	stores.Lock()
+	defer stores.Unlock()
+	if s.refs--; s.refs > 0 {
+		return nil
+	}
+	delete(stores.m, s.dsn)
+	_, err := s.v.Close()
+	return err
+}
+
+type conn struct {
+	store  *store
+	tx     bool
+	closed bool
+}
+
+var errTxOptions = errors.New("driver: transaction options are not supported")
+
+func (c *conn) Prepare(query string) (sqldriver.Stmt, error) {
+	return &stmt{conn: c, query: query, inputs: placeholders(query)}, nil
+}
+
+func (c *conn) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	if c.tx {
+		c.store.v.Rollback()
+		c.tx = false
+		c.store.Unlock()
+	}
+	return c.store.release()
+}
+
+func (c *conn) Begin() (sqldriver.Tx, error) {
+	return c.BeginTx(context.Background(), sqldriver.TxOptions{})
+}
+
+// BeginTx starts a Vedis write-transaction.
+// Read-only transactions and isolation levels are not supported.
+func (c *conn) BeginTx(ctx context.Context, opts sqldriver.TxOptions) (sqldriver.Tx, error) {
+	if opts.ReadOnly || opts.Isolation != sqldriver.IsolationLevel(sql.LevelDefault) {
+		return nil, errTxOptions
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.store.Lock()
+	if _, err := c.store.v.Begin(); err != nil {
+		c.store.Unlock()
+		return nil, err
+	}
+	c.tx = true
+	return &tx{conn: c}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []sqldriver.NamedValue) (sqldriver.Result, error) {
+	value, err := c.exec(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return result{value}, nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []sqldriver.NamedValue) (sqldriver.Rows, error) {
+	value, err := c.exec(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(value), nil
+}
+
+// Binds the arguments and runs the command, holding the datastore unless a transaction already does.
+func (c *conn) exec(ctx context.Context, query string, args []sqldriver.NamedValue) (interface{}, error) {
+	command, err := bind(query, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if !c.tx {
+		c.store.Lock()
+		defer c.store.Unlock()
+	}
+	return c.store.v.Exec(command)
+}
+
+type tx struct {
+	conn *conn
+}
+
+func (t *tx) Commit() error {
+	_, err := t.conn.store.v.Commit()
+	t.end()
+	return err
+}
+
+func (t *tx) Rollback() error {
+	_, err := t.conn.store.v.Rollback()
+	t.end()
+	return err
+}
+
+func (t *tx) end() {
+	if t.conn.tx {
+		t.conn.tx = false
+		t.conn.store.Unlock()
+	}
+}
+
+type stmt struct {
+	conn   *conn
+	query  string
+	inputs int
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return s.inputs
+}
+
+func (s *stmt) Exec(args []sqldriver.Value) (sqldriver.Result, error) {
+	return s.conn.ExecContext(context.Background(), s.query, named(args))
+}
+
+func (s *stmt) Query(args []sqldriver.Value) (sqldriver.Rows, error) {
+	return s.conn.QueryContext(context.Background(), s.query, named(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []sqldriver.NamedValue) (sqldriver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []sqldriver.NamedValue) (sqldriver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func named(args []sqldriver.Value) []sqldriver.NamedValue {
+	values := make([]sqldriver.NamedValue, len(args))
+	for i, arg := range args {
+		values[i] = sqldriver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return values
+}
+
+// The result of a command: integer results are reported as the number of affected rows,
+// true as one row and anything else as none.
+type result struct {
+	value interface{}
+}
+
+func (r result) LastInsertId() (int64, error) {
+	return 0, errors.New("driver: LastInsertId is not supported")
+}
+
+func (r result) RowsAffected() (int64, error) {
+	switch value := r.value.(type) {
+	case int64:
+		return value, nil
+	case bool:
+		if value {
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,92 @@
+package driver
+
+import (
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecAndQuery(t *testing.T) {
+	db, err := sql.Open("vedis", ":mem:")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+
+	result, err := db.Exec("HMSET ? ? ? ? ?", "config", "url", "github.com", "quote", `he said "hi"`)
+	if assert.NoError(t, err) {
+		affected, _ := result.RowsAffected()
+		assert.Equal(t, int64(2), affected)
+	}
+
+	var url string
+	assert.NoError(t, db.QueryRow("HGET ? ?", "config", "url").Scan(&url))
+	assert.Equal(t, "github.com", url)
+
+	var quote string
+	assert.NoError(t, db.QueryRow("HGET config ?", "quote").Scan(&quote))
+	assert.Equal(t, `he said "hi"`, quote)
+
+	rows, err := db.Query("HKEYS ?", "config")
+	if assert.NoError(t, err) {
+		var fields []string
+		for rows.Next() {
+			var field string
+			rows.Scan(&field)
+			fields = append(fields, field)
+		}
+		assert.NoError(t, rows.Err())
+		assert.Equal(t, []string{"url", "quote"}, fields)
+	}
+
+	var missing sql.NullString
+	assert.NoError(t, db.QueryRow("GET ?", "missing").Scan(&missing))
+	assert.False(t, missing.Valid)
+
+	// a placeholder inside a quoted string is left alone
+	var literal string
+	assert.NoError(t, db.QueryRow(`SET "what?" ?`, 42).Err())
+	assert.NoError(t, db.QueryRow(`GET "what?"`).Scan(&literal))
+	assert.Equal(t, "42", literal)
+}
+
+func TestBind(t *testing.T) {
+	_, err := bind("SET ? ?", named(nil))
+	assert.Error(t, err)
+
+	command, err := bind("SET ? ?", named([]sqldriver.Value{"it's", []byte("ok")}))
+	assert.NoError(t, err)
+	assert.Equal(t, `SET "it's" "ok"`, command)
+
+	for _, value := range []string{`both " and '`, `trailing \`, "nul \x00"} {
+		_, err := bind("SET key ?", named([]sqldriver.Value{value}))
+		assert.Error(t, err, value)
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	db, err := sql.Open("vedis", filepath.Join(t.TempDir(), "test.db"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if assert.NoError(t, err) {
+		tx.Exec("SET ? ?", "name", "John")
+		assert.NoError(t, tx.Commit())
+	}
+
+	tx, err = db.Begin()
+	if assert.NoError(t, err) {
+		tx.Exec("SET ? ?", "name", "Paul")
+		assert.NoError(t, tx.Rollback())
+	}
+
+	var name string
+	assert.NoError(t, db.QueryRow("GET name").Scan(&name))
+	assert.Equal(t, "John", name)
+}
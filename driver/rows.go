@@ -0,0 +1,47 @@
+package driver
+
+import (
+	sqldriver "database/sql/driver"
+	"encoding/json"
+	"io"
+)
+
+// Rows of a command result, one row for each element of an array result and a single row otherwise.
+// Nested arrays are returned as JSON encoded strings.
+type rows struct {
+	values []interface{}
+	next   int
+}
+
+func newRows(value interface{}) *rows {
+	if values, ok := value.([]interface{}); ok {
+		return &rows{values: values}
+	}
+	return &rows{values: []interface{}{value}}
+}
+
+func (r *rows) Columns() []string {
+	return []string{"value"}
+}
+
+func (r *rows) Close() error {
+	r.next = len(r.values)
+	return nil
+}
+
+func (r *rows) Next(dest []sqldriver.Value) error {
+	if r.next >= len(r.values) {
+		return io.EOF
+	}
+	value := r.values[r.next]
+	r.next++
+	if array, ok := value.([]interface{}); ok {
+		encoded, err := json.Marshal(array)
+		if err != nil {
+			return err
+		}
+		value = string(encoded)
+	}
+	dest[0] = value
+	return nil
+}
@@ -0,0 +1,263 @@
+package vedis
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// Dump stream layout: magic, version, records and a trailing CRC-32C of everything before it.
+// Each record is a type byte, the key and its value, strings are length prefixed (uvarint).
+const (
+	dumpMagic   = "VEDISDMP"
+	dumpVersion = 1
+
+	dumpEnd    = 0
+	dumpString = 1
+	dumpHash   = 2
+	dumpSet    = 3
+	dumpList   = 4
+)
+
+var dumpTypes = map[string]byte{
+	TypeString: dumpString,
+	TypeHash:   dumpHash,
+	TypeSet:    dumpSet,
+	TypeList:   dumpList,
+}
+
+var dumpTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Dump writes every key of the datastore, with its type and value, to w.
+// The stream is versioned and checksummed, and can be loaded back with Restore
+// into any datastore regardless of its storage engine.
+// It holds the keys as they were when Dump was called, see Backup.
+func (v *Vedis) Dump(w io.Writer) error {
+	_, err := v.dump(w, nil)
+	return err
+}
+
+// Writes the dump stream of a snapshot of the datastore, calling progress as Backup does,
+// and returns the checksum of the stream.
+func (v *Vedis) dump(w io.Writer, progress func(done, total int)) (uint32, error) {
+	out := newDumpWriter(w)
+	err := v.snapshot(progress, func(key keyInfo, value string, entries []entry) error {
+		out.key(key, value, entries)
+		return out.err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return out.close()
+}
+
+// Restore loads a stream written by Dump into the datastore.
+// Existing keys are replaced by the dumped ones, other keys are left untouched.
+// Each key is reported to watchers with the RESTORE operation.
+// The whole stream is read and verified before any key is written.
+func (v *Vedis) Restore(r io.Reader) error {
+	checksum := crc32.New(dumpTable)
+	in := &dumpReader{r: bufio.NewReader(r), sum: checksum}
+
+	magic := make([]byte, len(dumpMagic)+2)
+	if _, err := io.ReadFull(in, magic); err != nil || string(magic[:len(dumpMagic)]) != dumpMagic {
+		return ErrCorruptDump
+	}
+	if version := binary.BigEndian.Uint16(magic[len(dumpMagic):]); version > dumpVersion {
+		return fmt.Errorf("unsupported dump version %d", version)
+	}
+
+	type record struct {
+		typ     string
+		name    string
+		value   string
+		entries []entry
+	}
+	var records []record
+	for {
+		kind, err := in.ReadByte()
+		if err != nil {
+			return ErrCorruptDump
+		}
+		if kind == dumpEnd {
+			break
+		}
+		rec := record{name: in.string()}
+		switch kind {
+		case dumpString:
+			rec.typ, rec.value = TypeString, in.string()
+		case dumpHash, dumpSet, dumpList:
+			rec.typ = map[byte]string{dumpHash: TypeHash, dumpSet: TypeSet, dumpList: TypeList}[kind]
+			count := in.uvarint()
+			for i := uint64(0); i < count && in.err == nil; i++ {
+				switch kind {
+				case dumpHash:
+					rec.entries = append(rec.entries, entry{Key: in.string(), Data: in.string()})
+				case dumpSet:
+					rec.entries = append(rec.entries, entry{Key: in.string()})
+				case dumpList:
+					rec.entries = append(rec.entries, entry{Data: in.string()})
+				}
+			}
+		default:
+			return ErrCorruptDump
+		}
+		if in.err != nil {
+			return ErrCorruptDump
+		}
+		records = append(records, rec)
+	}
+
+	expected := checksum.Sum32()
+	sum := make([]byte, 4)
+	if _, err := io.ReadFull(in.r, sum); err != nil || binary.BigEndian.Uint32(sum) != expected {
+		return ErrCorruptDump
+	}
+
+	for _, rec := range records {
+		if err := v.load("RESTORE", rec.typ, rec.name, rec.value, rec.entries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Writes a key loaded from a dump or an export, holding value if it is a string and entries otherwise,
+// and reports it to watchers as changed by op.
+func (v *Vedis) load(op string, typ string, name string, value string, entries []entry) error {
+	var old string
+	var err error
+	if typ == TypeString {
+		old = v.peek(name)
+		err = v.store(name, value)
+	} else {
+		err = v.replace(typ, name, entries)
+	}
+	if err != nil {
+		return err
+	}
+	v.notify(Event{Key: name, Op: op, Old: old, New: value})
+	return nil
+}
+
+type dumpWriter struct {
+	out      io.Writer
+	w        *bufio.Writer
+	checksum hash.Hash32
+	err      error
+}
+
+// Starts a dump stream on w.
+func newDumpWriter(w io.Writer) *dumpWriter {
+	checksum := crc32.New(dumpTable)
+	d := &dumpWriter{out: w, w: bufio.NewWriter(io.MultiWriter(w, checksum)), checksum: checksum}
+	d.raw([]byte(dumpMagic))
+	d.raw([]byte{0, dumpVersion})
+	return d
+}
+
+// Writes the record of a key, holding value if it is a string and entries otherwise.
+func (d *dumpWriter) key(key keyInfo, value string, entries []entry) {
+	d.raw([]byte{dumpTypes[key.Type]})
+	d.string(key.Name)
+	if key.Type == TypeString {
+		d.string(value)
+		return
+	}
+	d.uvarint(uint64(len(entries)))
+	for _, e := range entries {
+		switch key.Type {
+		case TypeHash:
+			d.string(e.Key)
+			d.string(e.Data)
+		case TypeSet:
+			d.string(e.Key)
+		case TypeList:
+			d.string(e.Data)
+		}
+	}
+}
+
+// Ends the stream with its checksum, which is returned.
+func (d *dumpWriter) close() (uint32, error) {
+	d.raw([]byte{dumpEnd})
+	if d.err == nil {
+		d.err = d.w.Flush()
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	sum := d.checksum.Sum32()
+	_, err := d.out.Write(binary.BigEndian.AppendUint32(nil, sum))
+	return sum, err
+}
+
+func (d *dumpWriter) raw(p []byte) {
+	if d.err == nil {
+		_, d.err = d.w.Write(p)
+	}
+}
+
+func (d *dumpWriter) uvarint(n uint64) {
+	buf := make([]byte, binary.MaxVarintLen64)
+	d.raw(buf[:binary.PutUvarint(buf, n)])
+}
+
+func (d *dumpWriter) string(s string) {
+	d.uvarint(uint64(len(s)))
+	d.raw([]byte(s))
+}
+
+// Reads a dump stream, feeding every byte read to the checksum.
+type dumpReader struct {
+	r   *bufio.Reader
+	sum hash.Hash32
+	err error
+}
+
+func (d *dumpReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	d.sum.Write(p[:n])
+	return n, err
+}
+
+func (d *dumpReader) ReadByte() (byte, error) {
+	b, err := d.r.ReadByte()
+	if err == nil {
+		d.sum.Write([]byte{b})
+	}
+	return b, err
+}
+
+func (d *dumpReader) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	var n uint64
+	n, d.err = binary.ReadUvarint(d)
+	return n
+}
+
+func (d *dumpReader) string() string {
+	n := d.uvarint()
+	if d.err != nil {
+		return ""
+	}
+	// do not trust the length before reading the data, a corrupt stream could claim anything
+	var buf []byte
+	if n <= 1<<16 {
+		buf = make([]byte, n)
+		_, d.err = io.ReadFull(d, buf)
+	} else {
+		var b []byte
+		b, d.err = io.ReadAll(io.LimitReader(d, int64(n)))
+		if d.err == nil && uint64(len(b)) != n {
+			d.err = io.ErrUnexpectedEOF
+		}
+		buf = b
+	}
+	return string(buf)
+}
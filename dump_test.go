@@ -0,0 +1,137 @@
+package vedis
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+)
+
+func (suite *VedisTestSuite) populate(store *Vedis) {
+	store.Set("name", "John")
+	store.store("binary", "\xff\x00\"quoted\"")
+	store.HMSet("config", "url", "github.com", "timeout", "500")
+	store.Exec("SADD colors red green blue")
+	store.Exec("LPUSH queue first second")
+}
+
+func (suite *VedisTestSuite) assertPopulated(store *Vedis) {
+	if value, err := store.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("John", value)
+	}
+
+	if value, _, err := store.fetch("binary"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("\xff\x00\"quoted\"", value)
+	}
+
+	if hash, err := store.HGetAll("config"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]string{"url", "github.com", "timeout", "500"}, hash)
+	}
+
+	if members, err := store.Exec("SMEMBERS colors"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]interface{}{"red", "green", "blue"}, members)
+	}
+
+	if value, err := store.Exec("LPOP queue"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("first", value)
+	}
+}
+
+func (suite *VedisTestSuite) TestDumpAndRestore() {
+	suite.populate(suite.store)
+
+	var buffer bytes.Buffer
+	if err := suite.store.Dump(&buffer); err != nil {
+		suite.Fail(err.Error())
+	}
+
+	dir, err := os.MkdirTemp("", "vedis")
+	if err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	// from the in-memory engine to an on-disk datastore and back
+	disk := New()
+	disk.OpenFile(filepath.Join(dir, "test.db"))
+	if err := disk.Restore(bytes.NewReader(buffer.Bytes())); err != nil {
+		suite.Fail(err.Error())
+	}
+	disk.Close()
+
+	disk = New()
+	disk.OpenFile(filepath.Join(dir, "test.db"))
+	defer disk.Close()
+
+	var again bytes.Buffer
+	if err := disk.Dump(&again); err != nil {
+		suite.Fail(err.Error())
+	}
+	suite.Equal(buffer.Bytes(), again.Bytes())
+	suite.assertPopulated(disk)
+
+	memory := New()
+	memory.Open()
+	defer memory.Close()
+	if err := memory.Restore(&again); err != nil {
+		suite.Fail(err.Error())
+	}
+	suite.assertPopulated(memory)
+}
+
+func (suite *VedisTestSuite) TestRestoreCorrupt() {
+	suite.populate(suite.store)
+
+	var buffer bytes.Buffer
+	suite.store.Dump(&buffer)
+	data := buffer.Bytes()
+
+	corrupt := append([]byte(nil), data...)
+	corrupt[len(corrupt)/2] ^= 0xFF
+
+	store := New()
+	store.Open()
+	defer store.Close()
+
+	suite.Equal(ErrCorruptDump, store.Restore(bytes.NewReader(corrupt)))
+	suite.Equal(ErrCorruptDump, store.Restore(bytes.NewReader(data[:len(data)-1])))
+	suite.Equal(ErrCorruptDump, store.Restore(bytes.NewReader([]byte("nothing"))))
+
+	if exists, _ := store.Exists("name"); exists {
+		suite.Fail("corrupt dump partially restored")
+	}
+}
+
+func (suite *VedisTestSuite) TestRestoreEmptyTable() {
+	suite.store.HSet("config", "url", "github.com")
+	suite.store.Exec("SADD colors red")
+
+	// records of an empty hash and set clear the keys of their type
+	var buffer bytes.Buffer
+	out := newDumpWriter(&buffer)
+	out.key(keyInfo{TypeHash, "config"}, "", nil)
+	out.key(keyInfo{TypeSet, "colors"}, "", nil)
+	out.key(keyInfo{TypeString, "name"}, "John", nil)
+	if _, err := out.close(); err != nil {
+		suite.Fail(err.Error())
+	}
+	if err := suite.store.Restore(&buffer); err != nil {
+		suite.Fail(err.Error())
+	}
+
+	if keys, err := suite.store.keys(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]keyInfo{{TypeString, "name"}}, keys)
+	}
+}
@@ -0,0 +1,355 @@
+package vedis
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Suffix vedis appends to the path of a datastore to name its journal.
+const journalSuffix = "_vedis_journal"
+
+// Encrypted files start with a header holding a random salt and a tag proving the key,
+// followed by blocks of cryptBlock bytes, each sealed with XChaCha20-Poly1305 under a random nonce
+// and bound to the file and its position by the salt and the block index.
+// Every write of a block draws a new nonce, long enough at 192 bits not to collide however often blocks are rewritten.
+const (
+	cryptMagic     = "VEDISENC"
+	cryptVersion   = 2
+	cryptHeader    = 128
+	cryptBlock     = 4096
+	cryptSaltSize  = 16
+	cryptNonceSize = chacha20poly1305.NonceSizeX
+	cryptTagSize   = chacha20poly1305.Overhead
+	// used length, data, nonce and tag
+	cryptSealed = 4 + cryptBlock + cryptNonceSize + cryptTagSize
+)
+
+// Encrypt the datastore with key, 32 bytes long, using XChaCha20-Poly1305.
+// It must be called before OpenFile or OpenVFS, and only applies to on-disk datastores.
+// The datastore file and its journal are encrypted, opening them with another key fails with ErrBadKey.
+func (v *Vedis) Encrypt(key []byte) error {
+	if _, err := newAEAD(key); err != nil {
+		return err
+	}
+	v.key = bytes.Clone(key)
+	return nil
+}
+
+// EncryptVFS wraps vfs so that every file opened through it is encrypted with key, as Encrypt does.
+func EncryptVFS(vfs VFS, key []byte) (VFS, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &cryptVFS{vfs, aead}, nil
+}
+
+// Rekey rotates the key of the encrypted datastore stored at path, which must not be open.
+// The datastore is copied under the new key next to the original one, then renamed over it.
+func Rekey(path string, oldKey, newKey []byte) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	oldVFS, err := EncryptVFS(DirVFS(dir), oldKey)
+	if err != nil {
+		return err
+	}
+	newVFS, err := EncryptVFS(DirVFS(dir), newKey)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path + journalSuffix); err == nil {
+		return fmt.Errorf("rekey %s: the datastore has a journal, open and close it first", path)
+	}
+	src, err := oldVFS.OpenFile(name, os.O_RDONLY)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	tmp := name + ".rekey"
+	DirVFS(dir).Remove(tmp)
+	dst, err := newVFS.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_EXCL)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(io.NewOffsetWriter(dst, 0), io.NewSectionReader(src, 0, info.Size()))
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(filepath.Join(dir, tmp), path)
+	}
+	if err != nil {
+		DirVFS(dir).Remove(tmp)
+	}
+	return err
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption key: %w", err)
+	}
+	return aead, nil
+}
+
+// Opens the files of the datastore at path, if any, to fail early with ErrBadKey rather than on the first command.
+func checkKey(vfs VFS, path string) error {
+	for _, name := range []string{path, path + journalSuffix} {
+		f, err := vfs.OpenFile(name, os.O_RDONLY)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		f.Close()
+	}
+	return nil
+}
+
+type cryptVFS struct {
+	VFS
+	aead cipher.AEAD
+}
+
+func (c *cryptVFS) OpenFile(name string, flag int) (File, error) {
+	f, err := c.VFS.OpenFile(name, flag)
+	if err != nil {
+		return nil, err
+	}
+	file := &cryptFile{File: f, aead: c.aead}
+	if err := file.init(flag&(os.O_WRONLY|os.O_RDWR) != 0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open %s: %w", name, err)
+	}
+	return file, nil
+}
+
+func (c *cryptVFS) Stat(name string) (fs.FileInfo, error) {
+	f, err := c.OpenFile(name, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return f.Stat()
+}
+
+type cryptFile struct {
+	File
+	aead cipher.AEAD
+	salt []byte
+	// plain size of the file
+	size int64
+}
+
+// Writes the header of a new file, or checks the key against the header of an existing one.
+func (f *cryptFile) init(writable bool) error {
+	info, err := f.File.Stat()
+	if err != nil {
+		return err
+	}
+	header := make([]byte, cryptHeader)
+	if info.Size() == 0 {
+		if !writable {
+			return nil
+		}
+		copy(header, cryptMagic)
+		header[len(cryptMagic)] = cryptVersion
+		salt := header[12 : 12+cryptSaltSize]
+		rand.Read(salt)
+		nonce := header[28 : 28+cryptNonceSize]
+		rand.Read(nonce)
+		f.aead.Seal(header[:28+cryptNonceSize], nonce, nil, header[:28])
+		if _, err := f.File.WriteAt(header, 0); err != nil {
+			return err
+		}
+		f.salt = salt
+		return nil
+	}
+	if _, err := f.File.ReadAt(header, 0); err != nil {
+		return ErrBadKey
+	}
+	if string(header[:len(cryptMagic)]) != cryptMagic || header[len(cryptMagic)] != cryptVersion {
+		return ErrBadKey
+	}
+	nonce := header[28 : 28+cryptNonceSize]
+	if _, err := f.aead.Open(nil, nonce, header[28+cryptNonceSize:28+cryptNonceSize+cryptTagSize], header[:28]); err != nil {
+		return ErrBadKey
+	}
+	f.salt = header[12 : 12+cryptSaltSize]
+	blocks := (info.Size() - cryptHeader) / cryptSealed
+	if blocks > 0 {
+		last, err := f.readBlock(blocks - 1)
+		if err != nil {
+			return err
+		}
+		f.size = (blocks-1)*cryptBlock + int64(len(last))
+	}
+	return nil
+}
+
+// Returns the plain data of a block, nil past the end of the file.
+func (f *cryptFile) readBlock(index int64) ([]byte, error) {
+	if index*cryptBlock >= f.size && f.size > 0 {
+		return nil, nil
+	}
+	sealed := make([]byte, cryptSealed)
+	n, err := f.File.ReadAt(sealed, cryptHeader+index*cryptSealed)
+	if n == 0 && err == io.EOF {
+		return nil, nil
+	} else if n < len(sealed) {
+		return nil, fmt.Errorf("encrypted block %d: %w", index, io.ErrUnexpectedEOF)
+	}
+	plain, err := f.aead.Open(nil, sealed[:cryptNonceSize], sealed[cryptNonceSize:], f.blockData(index))
+	if err != nil {
+		return nil, fmt.Errorf("encrypted block %d: %w", index, err)
+	}
+	used := binary.BigEndian.Uint32(plain)
+	if used > cryptBlock {
+		return nil, fmt.Errorf("encrypted block %d: invalid length %d", index, used)
+	}
+	return plain[4 : 4+used], nil
+}
+
+func (f *cryptFile) writeBlock(index int64, data []byte) error {
+	plain := make([]byte, 4+cryptBlock)
+	binary.BigEndian.PutUint32(plain, uint32(len(data)))
+	copy(plain[4:], data)
+	sealed := make([]byte, cryptNonceSize, cryptSealed)
+	rand.Read(sealed)
+	sealed = f.aead.Seal(sealed, sealed, plain, f.blockData(index))
+	_, err := f.File.WriteAt(sealed, cryptHeader+index*cryptSealed)
+	return err
+}
+
+// Additional data binding a block to the file and its position.
+func (f *cryptFile) blockData(index int64) []byte {
+	return binary.BigEndian.AppendUint64(bytes.Clone(f.salt), uint64(index))
+}
+
+func (f *cryptFile) ReadAt(p []byte, off int64) (int, error) {
+	n := 0
+	for n < len(p) {
+		if off >= f.size {
+			return n, io.EOF
+		}
+		data, err := f.readBlock(off / cryptBlock)
+		if err != nil {
+			return n, err
+		}
+		inner := int(off % cryptBlock)
+		if inner >= len(data) {
+			return n, io.EOF
+		}
+		copied := copy(p[n:], data[inner:])
+		n += copied
+		off += int64(copied)
+	}
+	return n, nil
+}
+
+func (f *cryptFile) WriteAt(p []byte, off int64) (int, error) {
+	if f.salt == nil {
+		return 0, &fs.PathError{Op: "write", Path: "encrypted file", Err: fs.ErrPermission}
+	}
+	written := len(p)
+	if off > f.size {
+		// fill the gap with zeroes
+		p = append(make([]byte, off-f.size), p...)
+		off = f.size
+	}
+	for len(p) > 0 {
+		index := off / cryptBlock
+		inner := int(off % cryptBlock)
+		n := min(len(p), cryptBlock-inner)
+		var data []byte
+		if inner > 0 || n < cryptBlock {
+			// a whole block is overwritten without reading it, even when it is torn
+			var err error
+			if data, err = f.readBlock(index); err != nil {
+				return 0, err
+			}
+		}
+		if len(data) < inner+n {
+			data = append(data, make([]byte, inner+n-len(data))...)
+		}
+		copy(data[inner:], p[:n])
+		if err := f.writeBlock(index, data); err != nil {
+			return 0, err
+		}
+		p = p[n:]
+		off += int64(n)
+		f.size = max(f.size, off)
+	}
+	return written, nil
+}
+
+// Encrypted files are locked like the files they wrap.
+func (f *cryptFile) Lock(level LockLevel) error {
+	if l, ok := f.File.(LockFile); ok {
+		return l.Lock(level)
+	}
+	return nil
+}
+
+func (f *cryptFile) Unlock(level LockLevel) error {
+	if l, ok := f.File.(LockFile); ok {
+		return l.Unlock(level)
+	}
+	return nil
+}
+
+func (f *cryptFile) Truncate(size int64) error {
+	if size > f.size {
+		_, err := f.WriteAt(make([]byte, size-f.size), f.size)
+		return err
+	}
+	blocks := (size + cryptBlock - 1) / cryptBlock
+	if inner := size % cryptBlock; inner != 0 {
+		data, err := f.readBlock(blocks - 1)
+		if err != nil {
+			return err
+		}
+		if err := f.writeBlock(blocks-1, data[:inner]); err != nil {
+			return err
+		}
+	}
+	if err := f.File.Truncate(cryptHeader + blocks*cryptSealed); err != nil {
+		return err
+	}
+	f.size = size
+	return nil
+}
+
+func (f *cryptFile) Stat() (fs.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return cryptInfo{info, f.size}, nil
+}
+
+type cryptInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (i cryptInfo) Size() int64 { return i.size }
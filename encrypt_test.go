@@ -0,0 +1,158 @@
+package vedis
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func (suite *VedisTestSuite) TestEncrypt() {
+	key := bytes.Repeat([]byte{1}, 32)
+	vfs := NewMemVFS()
+	store := New()
+	suite.Error(store.Encrypt(key[:16]))
+	if err := store.Encrypt(key); err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	if _, err := store.OpenVFS("test.db", vfs); err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	store.Set("name", "John Doe")
+	store.HSet("config", "url", "github.com")
+	store.Commit()
+
+	// the journal keeps the previous content of the pages being changed
+	store.Begin()
+	store.Set("name", strings.Repeat("x", 5000))
+	if suite.Contains(vfs.files, "test.db_vedis_journal") {
+		suite.Greater(len(vfs.files["test.db_vedis_journal"].data), cryptHeader+cryptSealed)
+	}
+	for name, file := range vfs.files {
+		suite.NotContains(string(file.data), "John Doe", name)
+		suite.NotContains(string(file.data), "github.com", name)
+	}
+	store.Commit()
+	store.Close()
+
+	store = New()
+	suite.Error(store.Encrypt([]byte("short")))
+	store.Encrypt(bytes.Repeat([]byte{2}, 32))
+	_, err := store.OpenVFS("test.db", vfs)
+	suite.ErrorIs(err, ErrBadKey)
+
+	store = New()
+	store.Encrypt(key)
+	_, err = store.OpenFile(":mem:")
+	suite.Error(err)
+
+	store = New()
+	store.Encrypt(key)
+	store.OpenVFS("test.db", vfs)
+	defer store.Close()
+
+	if value, err := store.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(strings.Repeat("x", 5000), value)
+	}
+
+	if value, err := store.HGet("config", "url"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("github.com", value)
+	}
+}
+
+func (suite *VedisTestSuite) TestRekey() {
+	oldKey := bytes.Repeat([]byte{1}, 32)
+	newKey := bytes.Repeat([]byte{2}, 32)
+	path := filepath.Join(suite.T().TempDir(), "test.db")
+	store := New()
+	store.Encrypt(oldKey)
+	if _, err := store.OpenFile(path); err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	store.Set("name", "John")
+	store.Close()
+
+	if data, err := os.ReadFile(path); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.NotContains(string(data), "John")
+	}
+
+	suite.ErrorIs(Rekey(path, newKey, oldKey), ErrBadKey)
+	if err := Rekey(path, oldKey, newKey); err != nil {
+		suite.Fail(err.Error())
+	}
+	suite.NoFileExists(path + ".rekey")
+
+	store = New()
+	store.Encrypt(oldKey)
+	_, err := store.OpenFile(path)
+	suite.ErrorIs(err, ErrBadKey)
+
+	store = New()
+	store.Encrypt(newKey)
+	store.OpenFile(path)
+	defer store.Close()
+
+	if value, err := store.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("John", value)
+	}
+}
+
+func (suite *VedisTestSuite) TestEncryptTornBlock() {
+	key := bytes.Repeat([]byte{1}, 32)
+	mem := NewMemVFS()
+	vfs, _ := EncryptVFS(mem, key)
+	f, err := vfs.OpenFile("test.db", os.O_RDWR|os.O_CREATE)
+	if err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	defer f.Close()
+	page := bytes.Repeat([]byte{'a'}, cryptBlock)
+	f.WriteAt(page, 0)
+	f.WriteAt(page, cryptBlock)
+
+	// tear the second block, as a crash in the middle of its write would
+	raw, _ := mem.OpenFile("test.db", os.O_RDWR)
+	raw.WriteAt([]byte("torn"), cryptHeader+cryptSealed+100)
+	raw.Close()
+	_, err = f.ReadAt(make([]byte, 10), cryptBlock)
+	suite.Error(err)
+	_, err = f.WriteAt([]byte("partial"), cryptBlock+10)
+	suite.Error(err)
+
+	page = bytes.Repeat([]byte{'b'}, cryptBlock)
+	if _, err := f.WriteAt(page, cryptBlock); err != nil {
+		suite.Fail(err.Error())
+	}
+	read := make([]byte, cryptBlock)
+	if _, err := f.ReadAt(read, cryptBlock); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(page, read)
+	}
+
+	// encrypted files are locked like the files of the VFS they wrap
+	dir := suite.T().TempDir()
+	locked, _ := EncryptVFS(DirVFS(dir), key)
+	first, err := locked.OpenFile("test.db", os.O_RDWR|os.O_CREATE)
+	if err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	defer first.Close()
+	second, _ := locked.OpenFile("test.db", os.O_RDWR)
+	defer second.Close()
+	suite.NoError(first.(LockFile).Lock(LockExclusive))
+	suite.ErrorIs(second.(LockFile).Lock(LockShared), ErrLocked)
+}
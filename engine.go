@@ -0,0 +1,224 @@
+package vedis
+
+// #include <stdlib.h>
+// #include "vedis_extra.h"
+import "C"
+import (
+	"fmt"
+	"runtime/cgo"
+	"sync"
+	"unsafe"
+)
+
+// SeekMatch tells EngineCursor.Seek which record to land on when the key is missing.
+type SeekMatch int
+
+const (
+	// SeekExact only accepts the record of the key.
+	SeekExact SeekMatch = C.VEDIS_CURSOR_MATCH_EXACT
+	// SeekLE falls back to the closest record before the key.
+	SeekLE SeekMatch = C.VEDIS_CURSOR_MATCH_LE
+	// SeekGE falls back to the closest record after the key.
+	SeekGE SeekMatch = C.VEDIS_CURSOR_MATCH_GE
+)
+
+// StorageEngine is a key/value storage engine written in Go, the counterpart of vedis_kv_methods.
+// Strings are stored as they are, hashes, sets and lists as one record per entry plus a header record.
+// Vedis never calls an engine from several goroutines at once.
+//
+// See http://vedis.symisc.net/c_api/vedis_lib_config.html
+type StorageEngine interface {
+	// Open is called when a datastore starts using the engine.
+	Open() error
+	// Replace stores value at key, overwriting the previous value.
+	Replace(key, value []byte) error
+	// Append appends value to the value stored at key, creating the record if needed.
+	Append(key, value []byte) error
+	// Cursor returns a new cursor, positioned on the first record.
+	Cursor() EngineCursor
+	// Close is called when the datastore stops using the engine.
+	Close() error
+}
+
+// EngineCursor walks the records of a StorageEngine.
+// Engines without an order, like hash tables, may treat every seek as SeekExact.
+type EngineCursor interface {
+	// Seek moves to the record of key, or to its neighbour depending on match, reporting whether a record was found.
+	Seek(key []byte, match SeekMatch) bool
+	First() bool
+	Last() bool
+	Next() bool
+	Prev() bool
+	// Valid reports whether the cursor points to a record.
+	Valid() bool
+	Key() []byte
+	Value() []byte
+	// Delete removes the current record, moving to the next one.
+	Delete() error
+	Close()
+}
+
+// Names of the engines installed by RegisterEngine.
+var engines sync.Map
+
+// RegisterEngine installs a storage engine under name, for SetEngine to select.
+// Every datastore using the engine gets its own instance from open.
+// Engines can not be unregistered.
+func RegisterEngine(name string, open func() StorageEngine) error {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	handle := cgo.NewHandle(open)
+	if status := C.vedis_extra_register_engine(cname, C.uintptr_t(handle)); status != C.VEDIS_OK {
+		handle.Delete()
+		if status == C.VEDIS_EXISTS {
+			return Error{int(status), fmt.Sprintf("storage engine %q already registered", name)}
+		}
+		return Error{int(status), fmt.Sprintf("can not register storage engine %q", name)}
+	}
+	engines.Store(name, true)
+	return nil
+}
+
+// Switches the datastore to the storage engine registered under name, "hash" and "mem" being the builtin ones.
+// It must be called right after opening the datastore, before any key is read or written.
+// Only on-disk datastores keep their hashes, sets and lists in the storage engine, in-memory ones keep them in memory.
+// The engine is not recorded in the datastore file, so it has to be selected again every time the file is opened.
+// Records are handed to the engine as soon as they are written, which Rollback could not undo,
+// so Begin, and everything running a transaction, fails with ErrEngineTx on datastores using an engine of RegisterEngine.
+// Hashes, sets and lists are written back on Commit and Close.
+//
+// See http://vedis.symisc.net/c_api/vedis_config.html
+func (v *Vedis) SetEngine(name string) error {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	if status := C.vedis_extra_kv_engine(v.ptr, cname); status != C.VEDIS_OK {
+		return newError(status, v.ptr)
+	}
+	_, v.goEngine = engines.Load(name)
+	return nil
+}
+
+func engineStatus(err error) C.int {
+	if err != nil {
+		return C.VEDIS_IOERR
+	}
+	return C.VEDIS_OK
+}
+
+//export goEngineInit
+func goEngineInit(factory C.uintptr_t) C.uintptr_t {
+	engine := cgo.Handle(factory).Value().(func() StorageEngine)()
+	if engine == nil || engine.Open() != nil {
+		return 0
+	}
+	return C.uintptr_t(cgo.NewHandle(engine))
+}
+
+//export goEngineRelease
+func goEngineRelease(handle C.uintptr_t) {
+	cgo.Handle(handle).Value().(StorageEngine).Close()
+	cgo.Handle(handle).Delete()
+}
+
+//export goEngineWrite
+func goEngineWrite(handle C.uintptr_t, key unsafe.Pointer, keyLength C.int, data unsafe.Pointer, dataLength C.vedis_int64, appending C.int) C.int {
+	engine := cgo.Handle(handle).Value().(StorageEngine)
+	k := C.GoBytes(key, keyLength)
+	value := make([]byte, int(dataLength))
+	if dataLength > 0 {
+		copy(value, unsafe.Slice((*byte)(data), int(dataLength)))
+	}
+	if appending != 0 {
+		return engineStatus(engine.Append(k, value))
+	}
+	return engineStatus(engine.Replace(k, value))
+}
+
+//export goCursorInit
+func goCursorInit(engine C.uintptr_t) C.uintptr_t {
+	return C.uintptr_t(cgo.NewHandle(cgo.Handle(engine).Value().(StorageEngine).Cursor()))
+}
+
+//export goCursorSeek
+func goCursorSeek(handle C.uintptr_t, key unsafe.Pointer, keyLength C.int, match C.int) C.int {
+	if !cgo.Handle(handle).Value().(EngineCursor).Seek(C.GoBytes(key, keyLength), SeekMatch(match)) {
+		return C.VEDIS_NOTFOUND
+	}
+	return C.VEDIS_OK
+}
+
+//export goCursorMove
+func goCursorMove(handle C.uintptr_t, move C.int) C.int {
+	cursor := cgo.Handle(handle).Value().(EngineCursor)
+	switch move {
+	case C.VEDIS_EXTRA_FIRST:
+		cursor.First()
+	case C.VEDIS_EXTRA_LAST:
+		cursor.Last()
+	case C.VEDIS_EXTRA_NEXT:
+		if !cursor.Valid() {
+			return C.VEDIS_EOF
+		}
+		cursor.Next()
+	case C.VEDIS_EXTRA_PREV:
+		if !cursor.Valid() {
+			return C.VEDIS_EOF
+		}
+		cursor.Prev()
+	}
+	return C.VEDIS_OK
+}
+
+//export goCursorValid
+func goCursorValid(handle C.uintptr_t) C.int {
+	if cgo.Handle(handle).Value().(EngineCursor).Valid() {
+		return 1
+	}
+	return 0
+}
+
+//export goCursorDelete
+func goCursorDelete(handle C.uintptr_t) C.int {
+	cursor := cgo.Handle(handle).Value().(EngineCursor)
+	if !cursor.Valid() {
+		return C.VEDIS_NOTFOUND
+	}
+	return engineStatus(cursor.Delete())
+}
+
+//export goCursorLength
+func goCursorLength(handle C.uintptr_t, key C.int, length *C.vedis_int64) C.int {
+	cursor := cgo.Handle(handle).Value().(EngineCursor)
+	if !cursor.Valid() {
+		return C.VEDIS_EOF
+	}
+	if key != 0 {
+		*length = C.vedis_int64(len(cursor.Key()))
+	} else {
+		*length = C.vedis_int64(len(cursor.Value()))
+	}
+	return C.VEDIS_OK
+}
+
+// Copies the key or value of the current record in a buffer allocated with malloc, freed by the caller.
+//
+//export goCursorCopy
+func goCursorCopy(handle C.uintptr_t, key C.int, buffer *unsafe.Pointer, length *C.vedis_int64) C.int {
+	cursor := cgo.Handle(handle).Value().(EngineCursor)
+	if !cursor.Valid() {
+		return C.VEDIS_EOF
+	}
+	data := cursor.Value()
+	if key != 0 {
+		data = cursor.Key()
+	}
+	*buffer = C.CBytes(data)
+	*length = C.vedis_int64(len(data))
+	return C.VEDIS_OK
+}
+
+//export goCursorRelease
+func goCursorRelease(handle C.uintptr_t) {
+	cgo.Handle(handle).Value().(EngineCursor).Close()
+	cgo.Handle(handle).Delete()
+}
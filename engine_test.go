@@ -0,0 +1,149 @@
+package vedis
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// A storage engine keeping its records in a sorted slice, surviving Close so a datastore can be reopened.
+type sortedEngine struct {
+	keys   []string
+	values map[string][]byte
+	opened int
+}
+
+func (e *sortedEngine) Open() error {
+	e.opened++
+	return nil
+}
+
+func (e *sortedEngine) Replace(key, value []byte) error {
+	k := string(key)
+	if _, ok := e.values[k]; !ok {
+		i := sort.SearchStrings(e.keys, k)
+		e.keys = slices.Insert(e.keys, i, k)
+	}
+	e.values[k] = bytes.Clone(value)
+	return nil
+}
+
+func (e *sortedEngine) Append(key, value []byte) error {
+	return e.Replace(key, append(bytes.Clone(e.values[string(key)]), value...))
+}
+
+func (e *sortedEngine) Cursor() EngineCursor {
+	return &sortedCursor{e: e}
+}
+
+func (e *sortedEngine) Close() error {
+	return nil
+}
+
+type sortedCursor struct {
+	e *sortedEngine
+	i int
+}
+
+func (c *sortedCursor) Seek(key []byte, match SeekMatch) bool {
+	k := string(key)
+	c.i = sort.SearchStrings(c.e.keys, k)
+	if c.Valid() && c.e.keys[c.i] == k {
+		return true
+	}
+	switch match {
+	case SeekExact:
+		c.i = len(c.e.keys)
+	case SeekLE:
+		c.i--
+	}
+	return c.Valid()
+}
+
+func (c *sortedCursor) First() bool { c.i = 0; return c.Valid() }
+func (c *sortedCursor) Last() bool  { c.i = len(c.e.keys) - 1; return c.Valid() }
+func (c *sortedCursor) Next() bool  { c.i++; return c.Valid() }
+func (c *sortedCursor) Prev() bool  { c.i--; return c.Valid() }
+func (c *sortedCursor) Valid() bool { return c.i >= 0 && c.i < len(c.e.keys) }
+func (c *sortedCursor) Key() []byte { return []byte(c.e.keys[c.i]) }
+
+func (c *sortedCursor) Value() []byte {
+	return c.e.values[c.e.keys[c.i]]
+}
+
+func (c *sortedCursor) Delete() error {
+	delete(c.e.values, c.e.keys[c.i])
+	c.e.keys = slices.Delete(c.e.keys, c.i, c.i+1)
+	return nil
+}
+
+func (c *sortedCursor) Close() {}
+
+func (suite *VedisTestSuite) TestStorageEngine() {
+	engine := &sortedEngine{values: make(map[string][]byte)}
+	if err := RegisterEngine("sorted", func() StorageEngine { return engine }); err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	suite.Error(RegisterEngine("sorted", func() StorageEngine { return engine }))
+	suite.Error(suite.store.SetEngine("missing"))
+
+	path := filepath.Join(suite.T().TempDir(), "test.db")
+	store := New()
+	store.OpenFile(path)
+	if err := store.SetEngine("sorted"); err != nil {
+		suite.Fail(err.Error())
+	}
+	suite.Equal("sorted", store.EngineName())
+	// records handed to the engine can not be rolled back
+	_, err := store.Begin()
+	suite.ErrorIs(err, ErrEngineTx)
+	_, err = store.Exec("GET name; BEGIN")
+	suite.ErrorIs(err, ErrEngineTx)
+	suite.ErrorIs(store.WatchKeys("name").Exec(func() error { return nil }), ErrEngineTx)
+	store.Set("name", "John")
+	store.HSet("config", "url", "github.com")
+	store.Append("name", " Doe")
+	store.Set("age", "29")
+	store.Del("age")
+	store.Close()
+
+	suite.Equal("John Doe", string(engine.values["name"]))
+	suite.NotContains(engine.values, "age")
+	suite.Positive(engine.opened)
+
+	store = New()
+	store.OpenFile(path)
+	defer store.Close()
+	store.SetEngine("sorted")
+
+	if value, err := store.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("John Doe", value)
+	}
+
+	if value, err := store.HGet("config", "url"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("github.com", value)
+	}
+
+	if keys, err := store.keys(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]keyInfo{{TypeString, "name"}, {TypeHash, "config"}}, keys)
+	}
+
+	// Check does not write the header of the hash, only kept in memory until commit
+	header := string(engine.values["vt1config"])
+	store.HSet("config", "path", "/")
+	if report, err := store.Check(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(report.Healthy())
+		suite.Equal(2, report.Keys)
+	}
+	suite.Equal(header, string(engine.values["vt1config"]))
+}
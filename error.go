@@ -2,7 +2,34 @@ package vedis
 
 // #include "vedis_extra.h"
 import "C"
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTxConflict is returned by Tx.Exec when a watched key was changed before the transaction ran.
+var ErrTxConflict = errors.New("transaction aborted: watched key changed")
+
+// ErrCorruptDump is returned by Restore when the stream is truncated, malformed or fails its checksum.
+var ErrCorruptDump = errors.New("corrupt dump stream")
+
+// ErrReadOnly is returned by the commands writing to a datastore opened with OpenReadOnly.
+var ErrReadOnly = errors.New("read-only datastore")
+
+// ErrBadKey is returned when opening an encrypted datastore with the wrong key, or a datastore which is not encrypted.
+var ErrBadKey = errors.New("bad encryption key")
+
+// ErrBackupMismatch is returned by Backup when the datastore file written does not read back as the snapshot copied.
+var ErrBackupMismatch = errors.New("backup does not match the datastore")
+
+// ErrMemoryLimit is returned by the commands which could not allocate the memory they needed, see SetMemoryLimit.
+var ErrMemoryLimit = errors.New("memory limit exceeded")
+
+// ErrEngineTx is returned by Begin on a datastore using a storage engine of RegisterEngine, which can not roll back.
+var ErrEngineTx = errors.New("transactions are not supported by Go storage engines")
+
+// ErrLocked is returned by LockFile.Lock when another process holds a conflicting lock on the file.
+var ErrLocked = errors.New("file locked by another process")
 
 type Error struct {
 	Code    int
@@ -18,3 +45,20 @@ func newError(code C.int, ptr *C.vedis) Error {
 	C.vedis_error_message(ptr, &message)
 	return Error{int(code), C.GoString(message)}
 }
+
+// CodecError is returned by the typed wrappers when a value can not be encoded or decoded.
+type CodecError struct {
+	// Key holding the value.
+	Key string
+	// Op is "encode" or "decode".
+	Op  string
+	Err error
+}
+
+func (e *CodecError) Error() string {
+	return fmt.Sprintf("%s %q: %v", e.Op, e.Key, e.Err)
+}
+
+func (e *CodecError) Unwrap() error {
+	return e.Err
+}
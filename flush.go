@@ -0,0 +1,63 @@
+package vedis
+
+// #include "vedis_extra.h"
+import "C"
+import (
+	"os"
+	"time"
+)
+
+// Switch the datastore to manual commit, for bulk loads batching many writes per fsync.
+// Writes stay in the pending transaction until Flush, Sync or Commit, and are flushed by Close,
+// which reports the failure instead of silently rolling back as Vedis does on close.
+// With a positive interval, a write flushes the pending transaction once interval elapsed since the last flush.
+// The datastore is never used from another goroutine, so nothing is flushed while it is idle.
+//
+// See http://vedis.symisc.net/c_api/vedis_config.html
+func (v *Vedis) ManualCommit(interval time.Duration) error {
+	if status := C.vedis_extra_disable_auto_commit(v.ptr); status != C.VEDIS_OK {
+		return newError(status, v.ptr)
+	}
+	v.manual, v.interval, v.flushed = true, interval, time.Now()
+	return nil
+}
+
+// Flush commits the pending writes. Every Vedis commit syncs the journal and the datastore file,
+// so flushed writes survive a crash.
+func (v *Vedis) Flush() error {
+	if _, err := v.Commit(); err != nil {
+		return err
+	}
+	v.flushed = time.Now()
+	return nil
+}
+
+// Sync flushes the pending writes, then syncs the directory of the datastore file so that the creation
+// of the file and the removal of the journal survive a crash as well.
+// Datastores opened with OpenVFS or in memory have no directory to sync.
+func (v *Vedis) Sync() error {
+	if err := v.Flush(); err != nil {
+		return err
+	}
+	if v.dir == "" {
+		return nil
+	}
+	dir, err := os.Open(v.dir)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// Flushes the pending transaction after a write when the auto-flush interval elapsed,
+// unless a transaction was started by Begin.
+func (v *Vedis) autoFlush() error {
+	if v.interval <= 0 || time.Since(v.flushed) < v.interval {
+		return nil
+	}
+	if v.inTx() {
+		return nil
+	}
+	return v.Flush()
+}
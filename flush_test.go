@@ -0,0 +1,87 @@
+package vedis
+
+import (
+	"path/filepath"
+	"time"
+)
+
+func (suite *VedisTestSuite) TestManualCommit() {
+	path := filepath.Join(suite.T().TempDir(), "test.db")
+	journal := path + journalSuffix
+	store := New()
+	store.OpenFile(path)
+	if err := store.ManualCommit(0); err != nil {
+		suite.Fail(err.Error())
+	}
+	for _, name := range []string{"John", "Jane", "Jim"} {
+		store.Set(name, name)
+	}
+	suite.FileExists(journal)
+	if err := store.Flush(); err != nil {
+		suite.Fail(err.Error())
+	}
+	suite.NoFileExists(journal)
+	store.Set("name", "John")
+	suite.FileExists(journal)
+	if err := store.Sync(); err != nil {
+		suite.Fail(err.Error())
+	}
+	suite.NoFileExists(journal)
+
+	// pending writes are flushed by Close
+	store.Set("name", "Jane")
+	if _, err := store.Close(); err != nil {
+		suite.Fail(err.Error())
+	}
+	suite.NoFileExists(journal)
+	store = New()
+	store.OpenFile(path)
+	if value, err := store.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("Jane", value)
+	}
+
+	// auto-flush, but not inside a transaction
+	if err := store.ManualCommit(time.Millisecond); err != nil {
+		suite.Fail(err.Error())
+	}
+	time.Sleep(2 * time.Millisecond)
+	store.Set("name", "Jim")
+	suite.NoFileExists(journal)
+	// writes not going through a command are flushed as well
+	names := NewList(store, "names", JSONCodec[string]{})
+	names.Push("Jim")
+	suite.FileExists(journal)
+	time.Sleep(2 * time.Millisecond)
+	names.Push("Joe")
+	suite.NoFileExists(journal)
+	store.Begin()
+	time.Sleep(2 * time.Millisecond)
+	store.Set("name", "Joe")
+	suite.FileExists(journal)
+	store.Rollback()
+	if value, err := store.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("Jim", value)
+	}
+	store.Close()
+	suite.NoFileExists(journal)
+}
+
+func (suite *VedisTestSuite) TestManualCommitCloseFault() {
+	vfs := &faultyVFS{VFS: NewMemVFS()}
+	store := New()
+	store.OpenVFS("test.db", vfs)
+	store.ManualCommit(0)
+	store.Set("name", "John")
+	vfs.fail = true
+	if ok, err := store.Close(); err == nil {
+		suite.Fail("expected the flush to fail")
+	} else {
+		suite.False(ok)
+	}
+	// the handle is closed all the same
+	suite.Zero(vfs.open)
+}
@@ -1,18 +1,63 @@
 package vedis
 
-// #include "vedis.h"
+// #include <stdlib.h>
+// #include "vedis_extra.h"
 import "C"
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"unsafe"
 )
 
 func execute(v *Vedis, format string, values ...interface{}) error {
 	command := fmt.Sprintf(format, values...)
-	if status := C.vedis_exec(v.ptr, C.CString(command), -1); status != C.VEDIS_OK {
-		return newError(status, v.ptr)
+	if err := v.checkWrite(command); err != nil {
+		return err
 	}
-	return nil
+	err := allocating(func() error {
+		if status := C.vedis_exec(v.ptr, C.CString(command), -1); status != C.VEDIS_OK {
+			return newError(status, v.ptr)
+		}
+		return nil
+	})
+	if err != nil || !isWrite(command) {
+		return err
+	}
+	return v.autoFlush()
+}
+
+// Runs a command with binary safe arguments, which are not parsed by the command lexer.
+func call(v *Vedis, command string, args ...string) (*C.vedis_value, error) {
+	if err := v.checkWrite(command, args...); err != nil {
+		return nil, err
+	}
+	name := C.CString(command)
+	defer C.free(unsafe.Pointer(name))
+	pointers := unsafe.Slice((**C.char)(C.malloc(C.size_t(len(args)+1)*C.size_t(unsafe.Sizeof((*C.char)(nil))))), len(args)+1)
+	lengths := unsafe.Slice((*C.int)(C.malloc(C.size_t(len(args)+1)*C.size_t(unsafe.Sizeof(C.int(0))))), len(args)+1)
+	defer C.free(unsafe.Pointer(&pointers[0]))
+	defer C.free(unsafe.Pointer(&lengths[0]))
+	for i, arg := range args {
+		pointers[i] = C.CString(arg)
+		lengths[i] = C.int(len(arg))
+		defer C.free(unsafe.Pointer(pointers[i]))
+	}
+	err := allocating(func() error {
+		if status := C.vedis_extra_call(v.ptr, name, C.int(len(args)), &pointers[0], &lengths[0]); status != C.VEDIS_OK {
+			return newError(status, v.ptr)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if isWrite(command) {
+		if err := v.autoFlush(); err != nil {
+			return nil, err
+		}
+	}
+	return result(v)
 }
 
 func result(v *Vedis) (*C.vedis_value, error) {
@@ -43,6 +88,28 @@ func executeWithIntResult(v *Vedis, cmd string, values ...interface{}) (int, err
 	}
 }
 
+func executeWithInt64Result(v *Vedis, cmd string, values ...interface{}) (int64, error) {
+	if err := execute(v, cmd, values...); err != nil {
+		return 0, err
+	}
+	if result, err := result(v); err != nil {
+		return 0, err
+	} else {
+		return toInt64(result), nil
+	}
+}
+
+func executeWithFloatResult(v *Vedis, cmd string, values ...interface{}) (float64, error) {
+	if err := execute(v, cmd, values...); err != nil {
+		return 0, err
+	}
+	if result, err := result(v); err != nil {
+		return 0, err
+	} else {
+		return toFloat(result), nil
+	}
+}
+
 func executeWithStringResult(v *Vedis, cmd string, values ...interface{}) (string, error) {
 	if err := execute(v, cmd, values...); err != nil {
 		return "", err
@@ -80,5 +147,43 @@ func toString(value *C.vedis_value) string {
 }
 
 func toInt(value *C.vedis_value) int {
-	return int(C.vedis_value_to_int(value))
+	return int(C.vedis_value_to_int64(value))
+}
+
+func toInt64(value *C.vedis_value) int64 {
+	return int64(C.vedis_value_to_int64(value))
+}
+
+func toFloat(value *C.vedis_value) float64 {
+	return float64(C.vedis_value_to_double(value))
+}
+
+func toValue(value *C.vedis_value) interface{} {
+	switch {
+	case C.vedis_value_is_null(value) != 0:
+		return nil
+	case C.vedis_value_is_array(value) != 0:
+		count := int(C.vedis_array_count(value))
+		values := make([]interface{}, count)
+		for i := range values {
+			if elem := C.vedis_array_fetch(value, C.uint(i)); elem != nil {
+				values[i] = toValue(elem)
+			}
+		}
+		return values
+	case C.vedis_value_is_int(value) != 0:
+		return int64(C.vedis_value_to_int64(value))
+	case C.vedis_value_is_float(value) != 0:
+		return float64(C.vedis_value_to_double(value))
+	case C.vedis_value_is_bool(value) != 0:
+		return C.vedis_value_to_bool(value) != 0
+	default:
+		var length C.int
+		data := C.vedis_value_to_string(value, &length)
+		return C.GoStringN(data, length)
+	}
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
 }
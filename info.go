@@ -0,0 +1,89 @@
+package vedis
+
+// #include "vedis_extra.h"
+import "C"
+
+// Info describes the datastore and the Vedis library it runs on.
+type Info struct {
+	// LibVersion is the version of the Vedis library.
+	LibVersion string
+	// EngineName is the name of the storage engine ("mem" or "hash").
+	EngineName string
+	// ThreadSafe reports whether the library was built with thread support.
+	ThreadSafe bool
+	// Commands lists the installed commands.
+	Commands []string
+	// Tables lists the hashes, sets and lists loaded in memory.
+	Tables []string
+}
+
+// Returns the version of the Vedis library.
+//
+// See http://vedis.symisc.net/c_api/vedis_lib_version.html
+func LibVersion() string {
+	return C.GoString(C.vedis_lib_version())
+}
+
+// Reports whether the Vedis library was built with thread support.
+// This binding builds it without, so a datastore must not be used by several goroutines at once.
+//
+// See http://vedis.symisc.net/c_api/vedis_lib_is_threadsafe.html
+func IsThreadSafe() bool {
+	return C.vedis_lib_is_threadsafe() != 0
+}
+
+// Returns the name of the storage engine of the datastore.
+//
+// See http://vedis.symisc.net/c_api/vedis_config.html
+func (v *Vedis) EngineName() string {
+	var name *C.char
+	C.vedis_extra_kv_name(v.ptr, &name)
+	return C.GoString(name)
+}
+
+// Returns the names of the installed commands.
+//
+// See http://vedis.symisc.net/cmd/cmd_list.html
+func (v *Vedis) Commands() ([]string, error) {
+	if err := execute(v, "CMD_LIST"); err != nil {
+		return nil, err
+	}
+	value, err := result(v)
+	if err != nil {
+		return nil, err
+	}
+	return toStrings(toValue(value))
+}
+
+// Returns the names of the hashes, sets and lists loaded in memory.
+//
+// See http://vedis.symisc.net/cmd/table_list.html
+func (v *Vedis) Tables() ([]string, error) {
+	if err := execute(v, "TABLE_LIST"); err != nil {
+		return nil, err
+	}
+	value, err := result(v)
+	if err != nil {
+		return nil, err
+	}
+	return toStrings(toValue(value))
+}
+
+// Returns the description of the datastore and its library.
+func (v *Vedis) Info() (*Info, error) {
+	commands, err := v.Commands()
+	if err != nil {
+		return nil, err
+	}
+	tables, err := v.Tables()
+	if err != nil {
+		return nil, err
+	}
+	return &Info{
+		LibVersion: LibVersion(),
+		EngineName: v.EngineName(),
+		ThreadSafe: IsThreadSafe(),
+		Commands:   commands,
+		Tables:     tables,
+	}, nil
+}
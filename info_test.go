@@ -0,0 +1,17 @@
+package vedis
+
+func (suite *VedisTestSuite) TestInfo() {
+	suite.store.HSet("config", "url", "github.com")
+
+	info, err := suite.store.Info()
+	if err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	suite.Equal("1.2.6", info.LibVersion)
+	suite.Equal("mem", info.EngineName)
+	suite.False(info.ThreadSafe)
+	suite.Contains(info.Commands, "GET")
+	suite.Contains(info.Commands, "HINCRBY")
+	suite.Equal([]string{"config"}, info.Tables)
+}
@@ -0,0 +1,233 @@
+package vedis
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// A key as exported by ExportJSON.
+// Value is a string for strings, an object for hashes and an array for sets and lists.
+// When the key or any of its values is not valid UTF-8, Encoding is "base64" and every string of the record is base64 encoded.
+type jsonRecord struct {
+	Key      string          `json:"key"`
+	Type     string          `json:"type"`
+	Encoding string          `json:"encoding,omitempty"`
+	Value    json.RawMessage `json:"value"`
+}
+
+// ExportJSON writes every key accepted by filter to w as newline delimited JSON, one object per key:
+//
+//	{"key":"config","type":"hash","value":{"url":"github.com","timeout":"500"}}
+//
+// A nil filter exports every key. The output can be loaded back with ImportJSON.
+func (v *Vedis) ExportJSON(w io.Writer, filter func(key string, typ string) bool) error {
+	keys, err := v.keys()
+	if err != nil {
+		return err
+	}
+	out := bufio.NewWriter(w)
+	for _, key := range keys {
+		if filter != nil && !filter(key.Name, key.Type) {
+			continue
+		}
+		var values []string
+		var entries []entry
+		if key.Type == TypeString {
+			value, _, err := v.fetch(key.Name)
+			if err != nil {
+				return err
+			}
+			values = []string{value}
+		} else if entries, err = v.entries(key.Type, key.Name); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			values = append(values, e.Key, e.Data)
+		}
+
+		encode := func(s string) string { return s }
+		record := jsonRecord{Key: key.Name, Type: key.Type}
+		if !validUTF8(append(values, key.Name)) {
+			encode = func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+			record.Key, record.Encoding = encode(key.Name), "base64"
+		}
+
+		var value bytes.Buffer
+		switch key.Type {
+		case TypeString:
+			writeJSON(&value, encode(values[0]))
+		case TypeHash:
+			value.WriteByte('{')
+			for i, e := range entries {
+				if i > 0 {
+					value.WriteByte(',')
+				}
+				writeJSON(&value, encode(e.Key))
+				value.WriteByte(':')
+				writeJSON(&value, encode(e.Data))
+			}
+			value.WriteByte('}')
+		case TypeSet, TypeList:
+			value.WriteByte('[')
+			for i, e := range entries {
+				if i > 0 {
+					value.WriteByte(',')
+				}
+				if key.Type == TypeSet {
+					writeJSON(&value, encode(e.Key))
+				} else {
+					writeJSON(&value, encode(e.Data))
+				}
+			}
+			value.WriteByte(']')
+		}
+		record.Value = value.Bytes()
+
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		out.Write(line)
+		if err := out.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
+
+// ImportJSON loads keys written by ExportJSON from r.
+// Both newline delimited JSON and a JSON array of records are accepted.
+// Existing keys are replaced by the imported ones, each being reported to watchers with the IMPORT operation.
+func (v *Vedis) ImportJSON(r io.Reader) error {
+	in := bufio.NewReader(r)
+	array := false
+	for {
+		c, err := in.ReadByte()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			array = c == '['
+			in.UnreadByte()
+			break
+		}
+	}
+
+	decoder := json.NewDecoder(in)
+	if array {
+		decoder.Token()
+	}
+	for n := 1; decoder.More(); n++ {
+		var record jsonRecord
+		if err := decoder.Decode(&record); err != nil {
+			return fmt.Errorf("record %d: %v", n, err)
+		}
+		if err := v.importRecord(record); err != nil {
+			return fmt.Errorf("record %d: %v", n, err)
+		}
+	}
+	if array {
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *Vedis) importRecord(record jsonRecord) error {
+	decode := func(s string) (string, error) { return s, nil }
+	switch record.Encoding {
+	case "":
+	case "base64":
+		decode = func(s string) (string, error) {
+			b, err := base64.StdEncoding.DecodeString(s)
+			return string(b), err
+		}
+	default:
+		return fmt.Errorf("unknown encoding %q", record.Encoding)
+	}
+
+	name, err := decode(record.Key)
+	if err != nil {
+		return err
+	}
+
+	var entries []entry
+	switch record.Type {
+	case TypeString:
+		var value string
+		if err := json.Unmarshal(record.Value, &value); err != nil {
+			return err
+		}
+		if value, err = decode(value); err != nil {
+			return err
+		}
+		return v.load("IMPORT", TypeString, name, value, nil)
+	case TypeHash:
+		// decoded token by token to keep the fields order
+		decoder := json.NewDecoder(bytes.NewReader(record.Value))
+		if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+			return fmt.Errorf("hash value must be an object")
+		}
+		for decoder.More() {
+			var value string
+			token, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			field, ok := token.(string)
+			if !ok {
+				return fmt.Errorf("hash field must be a string")
+			}
+			if err := decoder.Decode(&value); err != nil {
+				return err
+			}
+			if field, err = decode(field); err != nil {
+				return err
+			}
+			if value, err = decode(value); err != nil {
+				return err
+			}
+			entries = append(entries, entry{Key: field, Data: value})
+		}
+	case TypeSet, TypeList:
+		var values []string
+		if err := json.Unmarshal(record.Value, &values); err != nil {
+			return err
+		}
+		for _, value := range values {
+			if value, err = decode(value); err != nil {
+				return err
+			}
+			if record.Type == TypeSet {
+				entries = append(entries, entry{Key: value})
+			} else {
+				entries = append(entries, entry{Data: value})
+			}
+		}
+	default:
+		return fmt.Errorf("unknown type %q", record.Type)
+	}
+	return v.load("IMPORT", record.Type, name, "", entries)
+}
+
+func validUTF8(strings []string) bool {
+	for _, s := range strings {
+		if !utf8.ValidString(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w *bytes.Buffer, s string) {
+	b, _ := json.Marshal(s)
+	w.Write(b)
+}
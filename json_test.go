@@ -0,0 +1,60 @@
+package vedis
+
+import (
+	"bytes"
+	"strings"
+)
+
+func (suite *VedisTestSuite) TestExportJSON() {
+	suite.populate(suite.store)
+
+	var buffer bytes.Buffer
+	if err := suite.store.ExportJSON(&buffer, nil); err != nil {
+		suite.Fail(err.Error())
+	}
+	suite.Equal(`{"key":"YmluYXJ5","type":"string","encoding":"base64","value":"/wAicXVvdGVkIg=="}
+{"key":"name","type":"string","value":"John"}
+{"key":"config","type":"hash","value":{"url":"github.com","timeout":"500"}}
+{"key":"colors","type":"set","value":["red","green","blue"]}
+{"key":"queue","type":"list","value":["first","second"]}
+`, buffer.String())
+
+	store := New()
+	store.Open()
+	defer store.Close()
+	if err := store.ImportJSON(&buffer); err != nil {
+		suite.Fail(err.Error())
+	}
+	suite.assertPopulated(store)
+}
+
+func (suite *VedisTestSuite) TestExportJSONFilter() {
+	suite.populate(suite.store)
+
+	var buffer bytes.Buffer
+	err := suite.store.ExportJSON(&buffer, func(key string, typ string) bool {
+		return typ == TypeHash
+	})
+	if err != nil {
+		suite.Fail(err.Error())
+	}
+	suite.Equal(`{"key":"config","type":"hash","value":{"url":"github.com","timeout":"500"}}`+"\n", buffer.String())
+}
+
+func (suite *VedisTestSuite) TestImportJSONArray() {
+	err := suite.store.ImportJSON(strings.NewReader(`[
+		{"key": "name", "type": "string", "value": "John"},
+		{"key": "config", "type": "hash", "value": {"url": "github.com"}}
+	]`))
+	if err != nil {
+		suite.Fail(err.Error())
+	}
+
+	if value, err := suite.store.HGet("config", "url"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("github.com", value)
+	}
+
+	suite.NotNil(suite.store.ImportJSON(strings.NewReader(`{"key": "name", "type": "zset", "value": []}`)))
+}
@@ -0,0 +1,346 @@
+package vedis
+
+// #include <stdlib.h>
+// #include "vedis_extra.h"
+import "C"
+import (
+	"runtime/cgo"
+	"sort"
+	"unsafe"
+)
+
+// Types of the values held by keys.
+const (
+	TypeNone   = "none"
+	TypeString = "string"
+	TypeHash   = "hash"
+	TypeSet    = "set"
+	TypeList   = "list"
+)
+
+var tableTypes = map[string]C.int{
+	TypeString: C.VEDIS_EXTRA_STRING,
+	TypeHash:   C.VEDIS_EXTRA_HASH,
+	TypeSet:    C.VEDIS_EXTRA_SET,
+	TypeList:   C.VEDIS_EXTRA_LIST,
+}
+
+// A key of the datastore with the type of its value.
+// Strings, hashes, sets and lists live in separate namespaces, so the same name may appear with several types.
+type keyInfo struct {
+	Type string
+	Name string
+}
+
+// An entry of a hash (field and value), set (member) or list (value).
+type entry struct {
+	Key  string
+	Data string
+}
+
+//export goVedisKey
+func goVedisKey(handle C.uintptr_t, kind C.int, name unsafe.Pointer, length C.int) {
+	visit := cgo.Handle(handle).Value().(func(keyInfo))
+	for typ, value := range tableTypes {
+		if value == kind {
+			visit(keyInfo{typ, C.GoStringN((*C.char)(name), length)})
+		}
+	}
+}
+
+//export goVedisEntry
+func goVedisEntry(handle C.uintptr_t, key unsafe.Pointer, keyLength C.int, data unsafe.Pointer, dataLength C.int) {
+	entries := cgo.Handle(handle).Value().(*[]entry)
+	*entries = append(*entries, entry{C.GoStringN((*C.char)(key), keyLength), C.GoStringN((*C.char)(data), dataLength)})
+}
+
+// Calls visit once for every non empty key of the datastore, without collecting them.
+func (v *Vedis) walk(visit func(keyInfo)) error {
+	handle := cgo.NewHandle(visit)
+	defer handle.Delete()
+	if status := C.vedis_extra_keys(v.ptr, C.uintptr_t(handle)); status != C.VEDIS_OK {
+		return newError(status, v.ptr)
+	}
+	return nil
+}
+
+// Returns every non empty key of the datastore, ordered by type and name.
+func (v *Vedis) keys() ([]keyInfo, error) {
+	var found []keyInfo
+	if err := v.walk(func(key keyInfo) { found = append(found, key) }); err != nil {
+		return nil, err
+	}
+	order := map[string]int{TypeString: 0, TypeHash: 1, TypeSet: 2, TypeList: 3}
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Type != found[j].Type {
+			return order[found[i].Type] < order[found[j].Type]
+		}
+		return found[i].Name < found[j].Name
+	})
+	return found, nil
+}
+
+// Returns the value of a string key, binary safe.
+func (v *Vedis) fetch(key string) (string, bool, error) {
+	name := C.CString(key)
+	defer C.free(unsafe.Pointer(name))
+	var length C.vedis_int64
+	status := C.vedis_kv_fetch(v.ptr, unsafe.Pointer(name), C.int(len(key)), nil, &length)
+	if status == C.VEDIS_NOTFOUND {
+		return "", false, nil
+	} else if status != C.VEDIS_OK {
+		return "", false, newError(status, v.ptr)
+	}
+	if length == 0 {
+		return "", true, nil
+	}
+	buffer := C.malloc(C.size_t(length))
+	defer C.free(buffer)
+	if status := C.vedis_kv_fetch(v.ptr, unsafe.Pointer(name), C.int(len(key)), buffer, &length); status != C.VEDIS_OK {
+		return "", false, newError(status, v.ptr)
+	}
+	value, err := v.unpack(C.GoStringN((*C.char)(buffer), C.int(length)))
+	return value, err == nil, err
+}
+
+// Reports whether a string key exists, without reading its value.
+func (v *Vedis) exists(key string) (bool, error) {
+	name := C.CString(key)
+	defer C.free(unsafe.Pointer(name))
+	var length C.vedis_int64
+	status := C.vedis_kv_fetch(v.ptr, unsafe.Pointer(name), C.int(len(key)), nil, &length)
+	if status == C.VEDIS_NOTFOUND {
+		return false, nil
+	} else if status != C.VEDIS_OK {
+		return false, newError(status, v.ptr)
+	}
+	return true, nil
+}
+
+// Stores the value of a string key unless it exists, reporting whether it was stored.
+func (v *Vedis) storeNX(key string, value string) (bool, error) {
+	if exists, err := v.exists(key); err != nil || exists {
+		return false, err
+	}
+	err := v.store(key, value)
+	return err == nil, err
+}
+
+// Removes a string key, binary safe.
+func (v *Vedis) delete(key string) error {
+	if err := v.beforeWrite(key); err != nil {
+		return err
+	}
+	name := C.CString(key)
+	defer C.free(unsafe.Pointer(name))
+	if status := C.vedis_kv_delete(v.ptr, unsafe.Pointer(name), C.int(len(key))); status != C.VEDIS_OK && status != C.VEDIS_NOTFOUND {
+		return newError(status, v.ptr)
+	}
+	return v.autoFlush()
+}
+
+// Returns the types of the values held by key, string first, then hash, set and list.
+// Tables are found by the type tag of their header record, so the same name is checked in every namespace.
+func (v *Vedis) types(key string) ([]string, error) {
+	var types []string
+	if ok, err := v.exists(key); err != nil {
+		return nil, err
+	} else if ok {
+		types = append(types, TypeString)
+	}
+	for _, typ := range []string{TypeHash, TypeSet, TypeList} {
+		if v.count(typ, key) > 0 {
+			types = append(types, typ)
+		}
+	}
+	return types, nil
+}
+
+// Stores the value of a string key, binary safe.
+func (v *Vedis) store(key string, value string) error {
+	if err := v.beforeWrite(key); err != nil {
+		return err
+	}
+	value, err := v.pack(value)
+	if err != nil {
+		return err
+	}
+	name, data := C.CString(key), C.CString(value)
+	defer C.free(unsafe.Pointer(name))
+	defer C.free(unsafe.Pointer(data))
+	err = allocating(func() error {
+		if status := C.vedis_kv_store(v.ptr, unsafe.Pointer(name), C.int(len(key)), unsafe.Pointer(data), C.vedis_int64(len(value))); status != C.VEDIS_OK {
+			return newError(status, v.ptr)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return v.autoFlush()
+}
+
+// Returns the entries of a hash, set or list in insertion order.
+func (v *Vedis) entries(typ string, name string) ([]entry, error) {
+	var entries []entry
+	var err error
+	handle := cgo.NewHandle(&entries)
+	defer handle.Delete()
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	status := C.vedis_extra_entries(v.ptr, tableTypes[typ], unsafe.Pointer(cname), C.int(len(name)), C.uintptr_t(handle))
+	if status != C.VEDIS_OK && status != C.VEDIS_NOTFOUND {
+		return nil, newError(status, v.ptr)
+	}
+	for i := range entries {
+		if entries[i], err = v.unpackEntry(typ, entries[i]); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// Replaces the entries of a hash, set or list.
+// Set members and hash fields are taken from the entry keys, hash and list values from the entry data.
+func (v *Vedis) replace(typ string, name string, entries []entry) error {
+	if err := v.beforeWrite(name); err != nil {
+		return err
+	}
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	if status := C.vedis_extra_clear(v.ptr, tableTypes[typ], unsafe.Pointer(cname), C.int(len(name))); status != C.VEDIS_OK {
+		return newError(status, v.ptr)
+	}
+	for _, e := range entries {
+		if err := v.put(typ, name, e); err != nil {
+			return err
+		}
+	}
+	return v.autoFlush()
+}
+
+// Inserts an entry in a hash, set or list, overwriting the value of an existing hash field.
+func (v *Vedis) insert(typ string, name string, e entry) error {
+	if err := v.beforeWrite(name); err != nil {
+		return err
+	}
+	if err := v.put(typ, name, e); err != nil {
+		return err
+	}
+	return v.autoFlush()
+}
+
+// Inserts an entry like insert, which runs the write hooks around it.
+func (v *Vedis) put(typ string, name string, e entry) error {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	var key, data unsafe.Pointer
+	if typ != TypeList {
+		key = unsafe.Pointer(C.CString(e.Key))
+		defer C.free(key)
+	}
+	if typ != TypeSet {
+		var err error
+		if e.Data, err = v.pack(e.Data); err != nil {
+			return err
+		}
+		data = unsafe.Pointer(C.CString(e.Data))
+		defer C.free(data)
+	}
+	return allocating(func() error {
+		status := C.vedis_extra_insert(v.ptr, tableTypes[typ], unsafe.Pointer(cname), C.int(len(name)), key, C.int(len(e.Key)), data, C.int(len(e.Data)))
+		if status != C.VEDIS_OK {
+			return newError(status, v.ptr)
+		}
+		return nil
+	})
+}
+
+// Returns the entry of a hash field or set member.
+func (v *Vedis) lookup(typ string, name string, key string) (entry, bool, error) {
+	var found []entry
+	handle := cgo.NewHandle(&found)
+	defer handle.Delete()
+	cname, ckey := C.CString(name), C.CString(key)
+	defer C.free(unsafe.Pointer(cname))
+	defer C.free(unsafe.Pointer(ckey))
+	status := C.vedis_extra_fetch(v.ptr, tableTypes[typ], unsafe.Pointer(cname), C.int(len(name)), unsafe.Pointer(ckey), C.int(len(key)), C.uintptr_t(handle))
+	if status == C.VEDIS_NOTFOUND {
+		return entry{}, false, nil
+	} else if status != C.VEDIS_OK {
+		return entry{}, false, newError(status, v.ptr)
+	}
+	e, err := v.unpackEntry(typ, found[0])
+	return e, err == nil, err
+}
+
+// Removes a hash field or set member, reporting whether it existed.
+func (v *Vedis) remove(typ string, name string, key string) (bool, error) {
+	if err := v.beforeWrite(name); err != nil {
+		return false, err
+	}
+	cname, ckey := C.CString(name), C.CString(key)
+	defer C.free(unsafe.Pointer(cname))
+	defer C.free(unsafe.Pointer(ckey))
+	status := C.vedis_extra_remove(v.ptr, tableTypes[typ], unsafe.Pointer(cname), C.int(len(name)), unsafe.Pointer(ckey), C.int(len(key)))
+	if status == C.VEDIS_NOTFOUND {
+		return false, nil
+	} else if status != C.VEDIS_OK {
+		return false, newError(status, v.ptr)
+	}
+	return true, v.autoFlush()
+}
+
+// Removes and returns the first entry of a list.
+func (v *Vedis) pop(typ string, name string) (entry, bool, error) {
+	if err := v.beforeWrite(name); err != nil {
+		return entry{}, false, err
+	}
+	var found []entry
+	handle := cgo.NewHandle(&found)
+	defer handle.Delete()
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	status := C.vedis_extra_pop(v.ptr, tableTypes[typ], unsafe.Pointer(cname), C.int(len(name)), C.uintptr_t(handle))
+	if status == C.VEDIS_NOTFOUND {
+		return entry{}, false, nil
+	} else if status != C.VEDIS_OK {
+		return entry{}, false, newError(status, v.ptr)
+	}
+	e, err := v.unpackEntry(typ, found[0])
+	if err != nil {
+		return e, false, err
+	}
+	return e, true, v.autoFlush()
+}
+
+// Runs before every write to keys, whatever the path it takes, autoFlush running after it.
+func (v *Vedis) beforeWrite(keys ...string) error {
+	if v.readOnly {
+		return ErrReadOnly
+	}
+	if v.snap != nil {
+		if err := v.snap.copy(keys); err != nil {
+			return err
+		}
+	}
+	v.touch(keys)
+	return nil
+}
+
+// Returns the number of entries of a hash, set or list.
+func (v *Vedis) count(typ string, name string) int {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	return int(C.vedis_extra_count(v.ptr, tableTypes[typ], unsafe.Pointer(cname), C.int(len(name))))
+}
+
+// Returns e with the value of a hash or list entry as it was before being stored.
+func (v *Vedis) unpackEntry(typ string, e entry) (entry, error) {
+	if typ == TypeSet {
+		return e, nil
+	}
+	var err error
+	e.Data, err = v.unpack(e.Data)
+	return e, err
+}
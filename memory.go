@@ -0,0 +1,98 @@
+package vedis
+
+// #include "vedis_extra.h"
+import "C"
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// MemoryStats reports the memory allocated by the engine, which the Go runtime does not account for.
+// Memory allocated by cgo to hand values over is not included.
+type MemoryStats struct {
+	// Bytes in use.
+	Allocated int64
+	// Largest number of bytes in use since the process started.
+	Peak int64
+	// Chunks in use.
+	Chunks int64
+	// Allocations refused because of the limit, or failed.
+	Failures int64
+	// Limit set by SetMemoryLimit, 0 when there is none.
+	Limit int64
+}
+
+var memoryError struct {
+	sync.Mutex
+	fn func()
+}
+
+// Set while a limit is set, commands then lock their thread to check their allocations.
+var memoryLimited atomic.Bool
+
+// Set when the allocator could not be installed, memory is then neither accounted for nor capped.
+var memoryInitError error
+
+// The allocator must be installed before the library is initialized, which opening the first datastore does.
+func init() {
+	if status := C.vedis_extra_memory_init(); status != C.VEDIS_OK {
+		memoryInitError = fmt.Errorf("can not install the memory allocator (%d)", status)
+	}
+}
+
+// SetMemoryLimit caps the memory the engine may allocate, for every datastore of the process, and returns the previous limit.
+// A limit of 0 or less removes the cap. Allocations beyond the limit fail, so do the commands needing them with ErrMemoryLimit,
+// and the function set by OnMemoryError is called.
+// While a limit is set, every command runs locked to the thread of its goroutine, which slows small writes
+// by about 5% (see BenchmarkMemoryLimit).
+// Without a limit, commands are not checked and a failed allocation makes them fail with the error of the engine.
+func SetMemoryLimit(bytes int64) (int64, error) {
+	if memoryInitError != nil {
+		return 0, memoryInitError
+	}
+	memoryLimited.Store(bytes > 0)
+	return int64(C.vedis_extra_memory_limit(C.vedis_int64(max(bytes, 0)))), nil
+}
+
+// OnMemoryError sets the function called when an allocation of the engine fails, nil removing it.
+// It runs on the goroutine of the failing call and must not use the datastore.
+func OnMemoryError(fn func()) {
+	memoryError.Lock()
+	defer memoryError.Unlock()
+	memoryError.fn = fn
+}
+
+// MemStats returns the memory allocated by the engine, for every datastore of the process.
+func MemStats() MemoryStats {
+	var used, peak, chunks, failures, limit C.vedis_int64
+	C.vedis_extra_memory_stats(&used, &peak, &chunks, &failures, &limit)
+	return MemoryStats{int64(used), int64(peak), int64(chunks), int64(failures), int64(limit)}
+}
+
+// Runs fn, a call into the engine, returning ErrMemoryLimit when one of its allocations failed.
+// Failures are recorded per thread, so fn runs locked to the thread of the goroutine, when a limit is set only.
+func allocating(fn func() error) error {
+	if !memoryLimited.Load() {
+		return fn()
+	}
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	C.vedis_extra_memory_failed()
+	err := fn()
+	if C.vedis_extra_memory_failed() != 0 {
+		return ErrMemoryLimit
+	}
+	return err
+}
+
+//export goMemoryError
+func goMemoryError() {
+	memoryError.Lock()
+	fn := memoryError.fn
+	memoryError.Unlock()
+	if fn != nil {
+		fn()
+	}
+}
@@ -0,0 +1,65 @@
+package vedis
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func (suite *VedisTestSuite) TestMemoryLimit() {
+	before := MemStats()
+	suite.Positive(before.Allocated)
+	suite.Positive(before.Chunks)
+	suite.GreaterOrEqual(before.Peak, before.Allocated)
+
+	failures := 0
+	OnMemoryError(func() { failures++ })
+	defer OnMemoryError(nil)
+	if previous, err := SetMemoryLimit(before.Allocated + 1024); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(int64(0), previous)
+	}
+	defer SetMemoryLimit(0)
+
+	_, err := suite.store.Set("big", strings.Repeat("x", 100000))
+	suite.ErrorIs(err, ErrMemoryLimit)
+	_, err = suite.store.Incr(strings.Repeat("x", 100000))
+	suite.ErrorIs(err, ErrMemoryLimit)
+	// below the threshold, the value is stored as it is
+	suite.store.Compress(GzipCompressor{}, 1<<20)
+	_, err = suite.store.Set("big", strings.Repeat("x", 100000))
+	suite.ErrorIs(err, ErrMemoryLimit)
+	suite.store.Compress(nil, 0)
+	suite.Positive(failures)
+	stats := MemStats()
+	suite.Equal(before.Allocated+1024, stats.Limit)
+	suite.LessOrEqual(stats.Allocated, stats.Limit)
+	suite.Greater(stats.Failures, before.Failures)
+
+	SetMemoryLimit(0)
+	if ok, err := suite.store.Set("big", strings.Repeat("x", 100000)); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+	suite.Greater(MemStats().Allocated, before.Allocated+100000)
+}
+
+func BenchmarkMemoryLimit(b *testing.B) {
+	value := strings.Repeat("x", 256)
+	for _, limit := range []int64{0, 1 << 40} {
+		b.Run(fmt.Sprintf("limit=%d", limit), func(b *testing.B) {
+			SetMemoryLimit(limit)
+			b.Cleanup(func() { SetMemoryLimit(0) })
+			store := New()
+			store.Open()
+			b.Cleanup(func() { store.Close() })
+			for i := 0; b.Loop(); i++ {
+				if _, err := store.Set(fmt.Sprintf("key:%d", i%1000), value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
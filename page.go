@@ -0,0 +1,90 @@
+package vedis
+
+// #include "vedis_extra.h"
+import "C"
+import (
+	"errors"
+	"fmt"
+)
+
+// Bounds of the page size and of the page cache.
+const (
+	MinPageSize     = 512
+	MaxPageSize     = 65536
+	DefaultPageSize = 4096
+	MinPageCache    = 256
+)
+
+// SetPageSize sets the page size, a power of two between MinPageSize and MaxPageSize, of the datastores created from now on.
+// The page size of a datastore is recorded in its file, so opening an existing file keeps the page size it was created with.
+// Larger pages favour long values and sequential scans, smaller ones random access to short values.
+// The page size of a single datastore is set by its SetPageSize method instead.
+//
+// See http://vedis.symisc.net/c_api/vedis_lib_config.html
+func SetPageSize(size int) error {
+	if status := C.vedis_extra_set_page_size(C.int(size)); status != C.VEDIS_OK {
+		return pageSizeError(size)
+	}
+	return nil
+}
+
+// PageSize returns the page size of the datastores created from now on.
+func PageSize() int {
+	return int(C.vedis_extra_page_size(nil))
+}
+
+// SetMaxPageCache sets the maximum number of pages, at least MinPageCache, kept in memory by the datastores opened from now on,
+// 0 removing the limit. The page cache of a single datastore is set by its SetMaxPageCache method instead.
+func SetMaxPageCache(pages int) error {
+	if status := C.vedis_extra_set_page_cache(C.int(pages)); status != C.VEDIS_OK {
+		return pageCacheError(pages)
+	}
+	return nil
+}
+
+// MaxPageCache returns the page cache of the datastores opened from now on, 0 when they have no limit.
+func MaxPageCache() int {
+	return int(C.vedis_extra_page_cache_size())
+}
+
+// Sets the page size of the datastore, overriding the one set by SetPageSize when its file is created.
+// It must be called before OpenFile or OpenVFS, and fails once the datastore is open.
+func (v *Vedis) SetPageSize(size int) error {
+	if v.ptr != nil {
+		return errors.New("can not set the page size of an open datastore")
+	}
+	if size < MinPageSize || size > MaxPageSize || size&(size-1) != 0 {
+		return pageSizeError(size)
+	}
+	v.pageSize = size
+	return nil
+}
+
+// Returns the page size of the datastore, which is the size set by SetPageSize until its file was read or written.
+func (v *Vedis) PageSize() int {
+	return int(C.vedis_extra_page_size(v.ptr))
+}
+
+// Sets the maximum number of pages the datastore keeps in memory, at least MinPageCache,
+// overriding the one set by SetMaxPageCache when it was opened.
+// Vedis treats it as a hint: unused clean pages are released right away, and dirty pages are written
+// to the file once more than 127 of them are pending, whatever the limit.
+//
+// See http://vedis.symisc.net/c_api/vedis_config.html
+func (v *Vedis) SetMaxPageCache(pages int) error {
+	if pages < MinPageCache {
+		return pageCacheError(pages)
+	}
+	if status := C.vedis_extra_max_page_cache(v.ptr, C.int(pages)); status != C.VEDIS_OK {
+		return newError(status, v.ptr)
+	}
+	return nil
+}
+
+func pageSizeError(size int) error {
+	return Error{C.VEDIS_INVALID, fmt.Sprintf("invalid page size %d, expecting a power of two between %d and %d", size, MinPageSize, MaxPageSize)}
+}
+
+func pageCacheError(pages int) error {
+	return Error{C.VEDIS_INVALID, fmt.Sprintf("invalid page cache size %d, expecting at least %d pages", pages, MinPageCache)}
+}
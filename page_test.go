@@ -0,0 +1,167 @@
+package vedis
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func (suite *VedisTestSuite) TestPageSize() {
+	for _, size := range []int{0, 256, 1000, 131072} {
+		suite.Error(SetPageSize(size))
+	}
+	suite.Equal(DefaultPageSize, PageSize())
+	if err := SetPageSize(8192); err != nil {
+		suite.Fail(err.Error())
+	}
+	defer SetPageSize(DefaultPageSize)
+
+	path := filepath.Join(suite.T().TempDir(), "test.db")
+	store := New()
+	store.OpenFile(path)
+	store.Set("name", "John")
+	suite.Equal(8192, store.PageSize())
+	store.Close()
+	if info, err := os.Stat(path); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Zero(info.Size() % 8192)
+	}
+
+	SetPageSize(DefaultPageSize)
+	store = New()
+	store.OpenFile(path)
+	defer store.Close()
+	if value, err := store.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("John", value)
+	}
+	suite.Equal(8192, store.PageSize())
+
+	suite.Error(store.SetMaxPageCache(MinPageCache - 1))
+	suite.NoError(store.SetMaxPageCache(4096))
+}
+
+func (suite *VedisTestSuite) TestStorePageSize() {
+	path := filepath.Join(suite.T().TempDir(), "test.db")
+	store := New()
+	for _, size := range []int{0, 256, 1000, 131072} {
+		suite.Error(store.SetPageSize(size))
+	}
+	if err := store.SetPageSize(16384); err != nil {
+		suite.Fail(err.Error())
+	}
+	store.OpenFile(path)
+	suite.Equal(16384, store.PageSize())
+	suite.Error(store.SetPageSize(8192))
+	store.Set("name", "John")
+	store.Close()
+	if info, err := os.Stat(path); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Zero(info.Size() % 16384)
+	}
+
+	// the library default is left as it was
+	suite.Equal(DefaultPageSize, PageSize())
+	other := New()
+	other.Open()
+	defer other.Close()
+	suite.Equal(DefaultPageSize, other.PageSize())
+
+	store = New()
+	store.OpenFile(path)
+	defer store.Close()
+	if value, err := store.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("John", value)
+	}
+	suite.Equal(16384, store.PageSize())
+}
+
+func (suite *VedisTestSuite) TestMaxPageCache() {
+	suite.Error(SetMaxPageCache(-1))
+	suite.Error(SetMaxPageCache(MinPageCache - 1))
+	suite.Zero(MaxPageCache())
+	if err := SetMaxPageCache(1024); err != nil {
+		suite.Fail(err.Error())
+	}
+	defer SetMaxPageCache(0)
+	suite.Equal(1024, MaxPageCache())
+
+	store := New()
+	store.OpenFile(filepath.Join(suite.T().TempDir(), "test.db"))
+	defer store.Close()
+	for i := 0; i < 1000; i++ {
+		store.Set(fmt.Sprintf("key:%d", i), strings.Repeat("x", 256))
+	}
+	if value, err := store.Get("key:0"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(strings.Repeat("x", 256), value)
+	}
+}
+
+// Opens a datastore in a temporary directory with the given page size and page cache.
+func benchmarkStore(b *testing.B, pageSize, pageCache int) *Vedis {
+	if err := SetPageSize(pageSize); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { SetPageSize(DefaultPageSize) })
+	store := New()
+	if _, err := store.OpenFile(filepath.Join(b.TempDir(), "bench.db")); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { store.Close() })
+	if err := store.SetMaxPageCache(pageCache); err != nil {
+		b.Fatal(err)
+	}
+	return store
+}
+
+var benchmarkSettings = []struct{ pageSize, pageCache int }{
+	{1024, MinPageCache},
+	{4096, MinPageCache},
+	{4096, 4096},
+	{16384, MinPageCache},
+	{65536, MinPageCache},
+}
+
+func BenchmarkWrite(b *testing.B) {
+	value := strings.Repeat("x", 256)
+	for _, setting := range benchmarkSettings {
+		b.Run(fmt.Sprintf("page=%d/cache=%d", setting.pageSize, setting.pageCache), func(b *testing.B) {
+			store := benchmarkStore(b, setting.pageSize, setting.pageCache)
+			b.SetBytes(int64(len(value)))
+			for i := 0; b.Loop(); i++ {
+				store.Set(fmt.Sprintf("key:%d", i), value)
+				if i%1000 == 999 {
+					store.Commit()
+				}
+			}
+			store.Commit()
+		})
+	}
+}
+
+func BenchmarkRead(b *testing.B) {
+	const keys = 10000
+	value := strings.Repeat("x", 256)
+	for _, setting := range benchmarkSettings {
+		b.Run(fmt.Sprintf("page=%d/cache=%d", setting.pageSize, setting.pageCache), func(b *testing.B) {
+			store := benchmarkStore(b, setting.pageSize, setting.pageCache)
+			for i := 0; i < keys; i++ {
+				store.Set(fmt.Sprintf("key:%d", i), value)
+			}
+			store.Commit()
+			b.SetBytes(int64(len(value)))
+			for i := 0; b.Loop(); i++ {
+				store.Get(fmt.Sprintf("key:%d", (i*7919)%keys))
+			}
+		})
+	}
+}
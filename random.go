@@ -0,0 +1,109 @@
+package vedis
+
+// #include <stdlib.h>
+// #include "vedis_extra.h"
+import "C"
+import "fmt"
+
+// Returns a pseudo random number from the PRNG of the datastore.
+//
+// See http://vedis.symisc.net/c_api/vedis_util_random_num.html
+func (v *Vedis) Rand() uint32 {
+	return uint32(C.vedis_util_random_num(v.ptr))
+}
+
+// Returns a pseudo random number between min and max, inclusive.
+//
+// See http://vedis.symisc.net/cmd/rand.html
+func (v *Vedis) RandRange(min, max uint32) (uint32, error) {
+	if min > max {
+		return 0, fmt.Errorf("RandRange: min %d is greater than max %d", min, max)
+	} else if min == max {
+		return min, nil
+	}
+	n, err := executeWithInt64Result(v, "RAND %d %d", min, max)
+	return uint32(n), err
+}
+
+// Returns the largest number Rand can return.
+//
+// See http://vedis.symisc.net/cmd/getrandmax.html
+func (v *Vedis) RandMax() (uint32, error) {
+	n, err := executeWithInt64Result(v, "GETRANDMAX")
+	return uint32(n), err
+}
+
+// Returns a pseudo random string of length lowercase letters.
+// Unlike RANDSTR, which falls back to 16 letters, any positive length is allowed.
+//
+// See http://vedis.symisc.net/c_api/vedis_util_random_string.html
+func (v *Vedis) RandStr(length int) (string, error) {
+	if length < 1 {
+		return "", fmt.Errorf("RandStr: invalid length %d", length)
+	}
+	// the engine wants room for at least three letters
+	size := max(length, 3)
+	buffer := C.malloc(C.size_t(size))
+	defer C.free(buffer)
+	if status := C.vedis_util_random_string(v.ptr, (*C.char)(buffer), C.uint(size)); status != C.VEDIS_OK {
+		return "", newError(status, v.ptr)
+	}
+	return C.GoStringN((*C.char)(buffer), C.int(length)), nil
+}
+
+// Returns random members of the set stored at key, just as Redis SRANDMEMBER with a count.
+// A positive count returns up to count distinct members, a negative count returns exactly -count members which may repeat.
+func (v *Vedis) SRandMember(key string, count int) ([]string, error) {
+	entries, err := v.entries(TypeSet, key)
+	if err != nil || len(entries) == 0 || count == 0 {
+		return []string{}, err
+	}
+	var members []string
+	if count < 0 {
+		for i := 0; i < -count; i++ {
+			members = append(members, entries[v.intn(len(entries))].Key)
+		}
+		return members, nil
+	}
+	// partial Fisher-Yates shuffle
+	count = min(count, len(entries))
+	for i := 0; i < count; i++ {
+		j := i + v.intn(len(entries)-i)
+		entries[i], entries[j] = entries[j], entries[i]
+		members = append(members, entries[i].Key)
+	}
+	return members, nil
+}
+
+// Returns up to n distinct keys picked at random from the whole datastore, whatever the type of their value,
+// so n keys as long as the datastore holds at least n names.
+// The keyspace is walked once without being collected, though the names met are kept to skip those
+// holding values of several types, so memory grows with the number of names.
+func (v *Vedis) SampleKeys(n int) ([]string, error) {
+	if n <= 0 {
+		return []string{}, nil
+	}
+	// reservoir sampling over the distinct names
+	sample := []string{}
+	names := make(map[string]bool)
+	err := v.walk(func(key keyInfo) {
+		if names[key.Name] {
+			return
+		}
+		names[key.Name] = true
+		if len(sample) < n {
+			sample = append(sample, key.Name)
+		} else if i := v.intn(len(names)); i < n {
+			sample[i] = key.Name
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sample, nil
+}
+
+// Returns a pseudo random number in [0, n).
+func (v *Vedis) intn(n int) int {
+	return int(uint64(v.Rand()) * uint64(n) >> 32)
+}
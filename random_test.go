@@ -0,0 +1,91 @@
+package vedis
+
+import "regexp"
+
+func (suite *VedisTestSuite) TestRand() {
+	if n, err := suite.store.RandRange(10, 20); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.GreaterOrEqual(n, uint32(10))
+		suite.LessOrEqual(n, uint32(20))
+	}
+
+	if n, err := suite.store.RandMax(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(uint32(0xFFFFFFFF), n)
+	}
+
+	for _, length := range []int{1, 16, 2048} {
+		if s, err := suite.store.RandStr(length); err != nil {
+			suite.Fail(err.Error())
+		} else {
+			suite.Len(s, length)
+			suite.Regexp(regexp.MustCompile("^[a-z]+$"), s)
+		}
+	}
+
+	_, err := suite.store.RandStr(0)
+	suite.Error(err)
+}
+
+func (suite *VedisTestSuite) TestSRandMember() {
+	NewSet(suite.store, "colors", StringCodec{}).Add("red", "green", "blue")
+
+	if members, err := suite.store.SRandMember("colors", 2); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Len(members, 2)
+		suite.NotEqual(members[0], members[1])
+		suite.Subset([]string{"red", "green", "blue"}, members)
+	}
+
+	if members, err := suite.store.SRandMember("colors", 5); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.ElementsMatch([]string{"red", "green", "blue"}, members)
+	}
+
+	if members, err := suite.store.SRandMember("colors", -5); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Len(members, 5)
+		suite.Subset([]string{"red", "green", "blue"}, members)
+	}
+
+	if members, err := suite.store.SRandMember("missing", 2); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Empty(members)
+	}
+}
+
+func (suite *VedisTestSuite) TestSampleKeys() {
+	suite.store.MSet("a", "1", "b", "2", "c", "3")
+	suite.store.HSet("d", "field", "value")
+	NewSet(suite.store, "e", StringCodec{}).Add("member")
+
+	if keys, err := suite.store.SampleKeys(3); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Len(keys, 3)
+		suite.Subset([]string{"a", "b", "c", "d", "e"}, keys)
+	}
+
+	if keys, err := suite.store.SampleKeys(10); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.ElementsMatch([]string{"a", "b", "c", "d", "e"}, keys)
+	}
+
+	// names holding values of several types count once
+	suite.store.HSet("a", "field", "value")
+	NewSet(suite.store, "b", StringCodec{}).Add("member")
+	for i := 0; i < 20; i++ {
+		if keys, err := suite.store.SampleKeys(5); err != nil {
+			suite.Fail(err.Error())
+		} else {
+			suite.ElementsMatch([]string{"a", "b", "c", "d", "e"}, keys)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package vedis
+
+import (
+	"io"
+	"time"
+
+	"github.com/go-zero/go-vedis/rdb"
+)
+
+// RDBReport summarizes an import from a Redis RDB file.
+type RDBReport struct {
+	// Keys is the number of imported keys.
+	Keys int
+	// Expires holds the expire time of the imported keys that had one in Redis.
+	// Vedis has no expiration, these keys are imported as persistent keys.
+	Expires map[string]time.Time
+	// Skipped lists the keys whose type is not supported (streams and modules).
+	Skipped []string
+}
+
+// ImportRDB loads the keys of a Redis RDB snapshot read from r.
+//
+// Strings, hashes, sets and lists are imported as such, sorted sets are imported as hashes of members to scores.
+// Keys of every Redis database are merged, existing keys are replaced by the imported ones,
+// each being reported to watchers with the IMPORT operation.
+// Keys are written as they are read, so an invalid file may be partially imported.
+func (v *Vedis) ImportRDB(r io.Reader) (*RDBReport, error) {
+	report := &RDBReport{Expires: map[string]time.Time{}}
+	decoder := rdb.NewDecoder(r)
+	for {
+		e, err := decoder.Next()
+		if err == io.EOF {
+			return report, nil
+		} else if err != nil {
+			return report, err
+		}
+		if e.Unsupported {
+			report.Skipped = append(report.Skipped, e.Key)
+			continue
+		}
+
+		var entries []entry
+		typ := e.Type
+		switch e.Type {
+		case rdb.TypeList, rdb.TypeSet:
+			for _, value := range e.Values {
+				if typ == TypeSet {
+					entries = append(entries, entry{Key: value})
+				} else {
+					entries = append(entries, entry{Data: value})
+				}
+			}
+		case rdb.TypeHash, rdb.TypeZSet:
+			typ = TypeHash
+			for i := 0; i+1 < len(e.Values); i += 2 {
+				entries = append(entries, entry{Key: e.Values[i], Data: e.Values[i+1]})
+			}
+		}
+		if err := v.load("IMPORT", typ, e.Key, e.Value, entries); err != nil {
+			return report, err
+		}
+
+		report.Keys++
+		if !e.Expiry.IsZero() {
+			report.Expires[e.Key] = e.Expiry
+		}
+	}
+}
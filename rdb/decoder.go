@@ -0,0 +1,711 @@
+// Package rdb decodes Redis RDB snapshot files.
+//
+// Strings, lists, sets, hashes and sorted sets are decoded in every encoding written by Redis
+// (ziplist, listpack, intset, zipmap, quicklist), together with their expire time.
+// Streams and modules are skipped and reported as unsupported entries.
+package rdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc64"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Types of the decoded entries.
+const (
+	TypeString = "string"
+	TypeList   = "list"
+	TypeSet    = "set"
+	TypeHash   = "hash"
+	TypeZSet   = "zset"
+	TypeStream = "stream"
+	TypeModule = "module"
+)
+
+// ErrChecksum is returned when the checksum at the end of the file does not match its content.
+var ErrChecksum = errors.New("rdb: checksum mismatch")
+
+// Entry is a key read from an RDB file.
+type Entry struct {
+	// DB is the database number holding the key.
+	DB int
+	// Key name.
+	Key string
+	// Type of the value, one of the Type constants.
+	Type string
+	// Value of a string.
+	Value string
+	// Values holds the elements of lists and sets, and field/value pairs of hashes.
+	// Sorted sets are stored as member/score pairs, scores being formatted floats.
+	Values []string
+	// Expiry is the time the key expires, zero when the key does not expire.
+	Expiry time.Time
+	// Unsupported is set for keys whose value was skipped (streams and modules).
+	Unsupported bool
+}
+
+// Decoder reads the entries of an RDB file.
+type Decoder struct {
+	r       *bufio.Reader
+	crc     hash.Hash64
+	version int
+	db      int
+	done    bool
+}
+
+// RDB opcodes
+const (
+	opSlotInfo     = 0xF4
+	opFunction2    = 0xF5
+	opFunctionPre  = 0xF6
+	opModuleAux    = 0xF7
+	opIdle         = 0xF8
+	opFreq         = 0xF9
+	opAux          = 0xFA
+	opResizeDB     = 0xFB
+	opExpireTimeMs = 0xFC
+	opExpireTime   = 0xFD
+	opSelectDB     = 0xFE
+	opEOF          = 0xFF
+)
+
+// RDB value types
+const (
+	typeString           = 0
+	typeList             = 1
+	typeSet              = 2
+	typeZSet             = 3
+	typeHash             = 4
+	typeZSet2            = 5
+	typeModulePreGA      = 6
+	typeModule2          = 7
+	typeHashZipmap       = 9
+	typeListZiplist      = 10
+	typeSetIntset        = 11
+	typeZSetZiplist      = 12
+	typeHashZiplist      = 13
+	typeListQuicklist    = 14
+	typeStreamListpacks  = 15
+	typeHashListpack     = 16
+	typeZSetListpack     = 17
+	typeListQuicklist2   = 18
+	typeStreamListpacks2 = 19
+	typeSetListpack      = 20
+	typeStreamListpacks3 = 21
+	typeHashMetadata     = 24
+	typeHashListpackEx   = 25
+)
+
+// The CRC-64 variant used by Redis (Jones polynomial, reflected).
+var crcTable = crc64.MakeTable(0x95AC9329AC4BC9B5)
+
+// NewDecoder returns a decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), crc: crc64.New(crcTable), version: -1}
+}
+
+// Next returns the next entry of the file, or io.EOF once every entry was read and the checksum verified.
+func (d *Decoder) Next() (*Entry, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+	if d.version < 0 {
+		if err := d.header(); err != nil {
+			return nil, err
+		}
+	}
+
+	var expiry time.Time
+	for {
+		op, err := d.byte()
+		if err != nil {
+			return nil, d.unexpected(err)
+		}
+		switch op {
+		case opEOF:
+			d.done = true
+			if d.version >= 5 {
+				expected := d.crc.Sum64()
+				sum := make([]byte, 8)
+				if _, err := io.ReadFull(d.r, sum); err != nil {
+					return nil, d.unexpected(err)
+				}
+				if checksum := binary.LittleEndian.Uint64(sum); checksum != 0 && checksum != expected {
+					return nil, ErrChecksum
+				}
+			}
+			return nil, io.EOF
+		case opSelectDB:
+			db, err := d.length()
+			if err != nil {
+				return nil, err
+			}
+			d.db = int(db)
+		case opResizeDB:
+			if _, err := d.length(); err != nil {
+				return nil, err
+			}
+			if _, err := d.length(); err != nil {
+				return nil, err
+			}
+		case opAux:
+			if _, err := d.string(); err != nil {
+				return nil, err
+			}
+			if _, err := d.string(); err != nil {
+				return nil, err
+			}
+		case opExpireTime:
+			b, err := d.bytes(4)
+			if err != nil {
+				return nil, err
+			}
+			expiry = time.Unix(int64(binary.LittleEndian.Uint32(b)), 0)
+		case opExpireTimeMs:
+			b, err := d.bytes(8)
+			if err != nil {
+				return nil, err
+			}
+			ms := int64(binary.LittleEndian.Uint64(b))
+			expiry = time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))
+		case opFreq:
+			if _, err := d.byte(); err != nil {
+				return nil, d.unexpected(err)
+			}
+		case opIdle:
+			if _, err := d.length(); err != nil {
+				return nil, err
+			}
+		case opSlotInfo:
+			for i := 0; i < 3; i++ {
+				if _, err := d.length(); err != nil {
+					return nil, err
+				}
+			}
+		case opFunction2:
+			if _, err := d.string(); err != nil {
+				return nil, err
+			}
+		case opFunctionPre:
+			return nil, fmt.Errorf("rdb: unsupported pre-release functions opcode")
+		case opModuleAux:
+			if _, err := d.length(); err != nil { // module id
+				return nil, err
+			}
+			// when, saved as an unsigned integer of the module serialization format
+			if op, err := d.length(); err != nil {
+				return nil, err
+			} else if op != 2 {
+				return nil, fmt.Errorf("rdb: invalid module aux when opcode %d", op)
+			}
+			if _, err := d.length(); err != nil {
+				return nil, err
+			}
+			if err := d.skipModule(); err != nil {
+				return nil, err
+			}
+		default:
+			e := &Entry{DB: d.db, Expiry: expiry}
+			if e.Key, err = d.string(); err != nil {
+				return nil, err
+			}
+			if err := d.value(op, e); err != nil {
+				return nil, fmt.Errorf("rdb: key %q: %w", e.Key, err)
+			}
+			return e, nil
+		}
+	}
+}
+
+func (d *Decoder) header() error {
+	b, err := d.bytes(9)
+	if err != nil || string(b[:5]) != "REDIS" {
+		return fmt.Errorf("rdb: not a RDB file")
+	}
+	version, err := strconv.Atoi(string(b[5:]))
+	if err != nil {
+		return fmt.Errorf("rdb: invalid version %q", b[5:])
+	}
+	d.version = version
+	return nil
+}
+
+// Decodes a value of the given type into the entry.
+func (d *Decoder) value(typ byte, e *Entry) error {
+	var err error
+	switch typ {
+	case typeString:
+		e.Type = TypeString
+		e.Value, err = d.string()
+	case typeList, typeSet:
+		e.Type = map[byte]string{typeList: TypeList, typeSet: TypeSet}[typ]
+		e.Values, err = d.strings(1)
+	case typeHash:
+		e.Type = TypeHash
+		e.Values, err = d.strings(2)
+	case typeZSet, typeZSet2:
+		e.Type = TypeZSet
+		err = d.zset(typ, e)
+	case typeHashZipmap:
+		e.Type = TypeHash
+		err = d.encoded(e, zipmap)
+	case typeListZiplist:
+		e.Type = TypeList
+		err = d.encoded(e, ziplist)
+	case typeZSetZiplist:
+		e.Type = TypeZSet
+		err = d.encoded(e, ziplist)
+	case typeHashZiplist:
+		e.Type = TypeHash
+		err = d.encoded(e, ziplist)
+	case typeSetIntset:
+		e.Type = TypeSet
+		err = d.encoded(e, intset)
+	case typeHashListpack:
+		e.Type = TypeHash
+		err = d.encoded(e, listpack)
+	case typeZSetListpack:
+		e.Type = TypeZSet
+		err = d.encoded(e, listpack)
+	case typeSetListpack:
+		e.Type = TypeSet
+		err = d.encoded(e, listpack)
+	case typeListQuicklist:
+		e.Type = TypeList
+		err = d.quicklist(e, false)
+	case typeListQuicklist2:
+		e.Type = TypeList
+		err = d.quicklist(e, true)
+	case typeHashMetadata:
+		e.Type = TypeHash
+		err = d.hashMetadata(e)
+	case typeHashListpackEx:
+		e.Type = TypeHash
+		err = d.hashListpackEx(e)
+	case typeStreamListpacks, typeStreamListpacks2, typeStreamListpacks3:
+		e.Type, e.Unsupported = TypeStream, true
+		err = d.skipStream(typ)
+	case typeModule2:
+		e.Type, e.Unsupported = TypeModule, true
+		if _, err = d.length(); err == nil { // module id
+			err = d.skipModule()
+		}
+	case typeModulePreGA:
+		err = fmt.Errorf("modules serialized before Redis 4.0 GA cannot be skipped")
+	default:
+		err = fmt.Errorf("unsupported value type %d", typ)
+	}
+	return err
+}
+
+// Reads count*n strings, n strings for each of the count elements.
+func (d *Decoder) strings(n int) ([]string, error) {
+	count, err := d.length()
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for i := uint64(0); i < count*uint64(n); i++ {
+		s, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, s)
+	}
+	return values, nil
+}
+
+func (d *Decoder) zset(typ byte, e *Entry) error {
+	count, err := d.length()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		member, err := d.string()
+		if err != nil {
+			return err
+		}
+		var score float64
+		if typ == typeZSet2 {
+			b, err := d.bytes(8)
+			if err != nil {
+				return err
+			}
+			score = math.Float64frombits(binary.LittleEndian.Uint64(b))
+		} else if score, err = d.float(); err != nil {
+			return err
+		}
+		e.Values = append(e.Values, member, formatScore(score))
+	}
+	return nil
+}
+
+// Reads an encoded blob (ziplist, listpack, ...) and appends its elements to the entry.
+func (d *Decoder) encoded(e *Entry, decode func([]byte) ([]string, error)) error {
+	blob, err := d.string()
+	if err != nil {
+		return err
+	}
+	values, err := decode([]byte(blob))
+	if err != nil {
+		return err
+	}
+	if e.Type == TypeZSet {
+		for i := 1; i < len(values); i += 2 {
+			if score, err := strconv.ParseFloat(values[i], 64); err == nil {
+				values[i] = formatScore(score)
+			}
+		}
+	}
+	e.Values = append(e.Values, values...)
+	return nil
+}
+
+func (d *Decoder) quicklist(e *Entry, v2 bool) error {
+	count, err := d.length()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		container := uint64(2)
+		if v2 {
+			if container, err = d.length(); err != nil {
+				return err
+			}
+		}
+		switch {
+		case !v2:
+			err = d.encoded(e, ziplist)
+		case container == 1: // plain node, a single element
+			var s string
+			if s, err = d.string(); err == nil {
+				e.Values = append(e.Values, s)
+			}
+		default:
+			err = d.encoded(e, listpack)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Hash with field expiration, stored as a dictionary.
+func (d *Decoder) hashMetadata(e *Entry) error {
+	if _, err := d.bytes(8); err != nil { // minimum expire time
+		return err
+	}
+	count, err := d.length()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		if _, err := d.length(); err != nil { // field ttl
+			return err
+		}
+		field, err := d.string()
+		if err != nil {
+			return err
+		}
+		value, err := d.string()
+		if err != nil {
+			return err
+		}
+		e.Values = append(e.Values, field, value)
+	}
+	return nil
+}
+
+// Hash with field expiration, stored as a listpack of field, value, ttl triplets.
+func (d *Decoder) hashListpackEx(e *Entry) error {
+	if _, err := d.bytes(8); err != nil { // minimum expire time
+		return err
+	}
+	blob, err := d.string()
+	if err != nil {
+		return err
+	}
+	values, err := listpack([]byte(blob))
+	if err != nil {
+		return err
+	}
+	for i := 0; i+2 < len(values); i += 3 {
+		e.Values = append(e.Values, values[i], values[i+1])
+	}
+	return nil
+}
+
+func (d *Decoder) skipStream(typ byte) error {
+	listpacks, err := d.length()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < listpacks*2; i++ { // master id and listpack
+		if _, err := d.string(); err != nil {
+			return err
+		}
+	}
+	// length, last id (ms, seq)
+	if err := d.lengths(3); err != nil {
+		return err
+	}
+	if typ >= typeStreamListpacks2 {
+		// first id (ms, seq), max deleted id (ms, seq), entries added
+		if err := d.lengths(5); err != nil {
+			return err
+		}
+	}
+	groups, err := d.length()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < groups; i++ {
+		if _, err := d.string(); err != nil { // name
+			return err
+		}
+		if err := d.lengths(2); err != nil { // last id
+			return err
+		}
+		if typ >= typeStreamListpacks2 {
+			if err := d.lengths(1); err != nil { // entries read
+				return err
+			}
+		}
+		pending, err := d.length()
+		if err != nil {
+			return err
+		}
+		for j := uint64(0); j < pending; j++ {
+			if _, err := d.bytes(16 + 8); err != nil { // id and delivery time
+				return err
+			}
+			if err := d.lengths(1); err != nil { // delivery count
+				return err
+			}
+		}
+		consumers, err := d.length()
+		if err != nil {
+			return err
+		}
+		for j := uint64(0); j < consumers; j++ {
+			if _, err := d.string(); err != nil { // name
+				return err
+			}
+			times := 8 // seen time
+			if typ >= typeStreamListpacks3 {
+				times += 8 // active time
+			}
+			if _, err := d.bytes(times); err != nil {
+				return err
+			}
+			pending, err := d.length()
+			if err != nil {
+				return err
+			}
+			if _, err := d.bytes(int(pending) * 16); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Module values serialized with the version 2 format can be skipped thanks to their opcodes.
+func (d *Decoder) skipModule() error {
+	for {
+		op, err := d.length()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case 0: // EOF
+			return nil
+		case 1, 2: // signed and unsigned integers
+			_, err = d.length()
+		case 3: // float
+			_, err = d.bytes(4)
+		case 4: // double
+			_, err = d.bytes(8)
+		case 5: // string
+			_, err = d.string()
+		default:
+			return fmt.Errorf("unknown module opcode %d", op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (d *Decoder) lengths(n int) error {
+	for i := 0; i < n; i++ {
+		if _, err := d.length(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Decoder) byte() (byte, error) {
+	b, err := d.r.ReadByte()
+	if err == nil {
+		d.crc.Write([]byte{b})
+	}
+	return b, err
+}
+
+func (d *Decoder) bytes(n int) ([]byte, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("rdb: invalid length")
+	}
+	b := make([]byte, 0, min(n, 1<<16))
+	chunk := make([]byte, min(n, 1<<16))
+	for len(b) < n {
+		read, err := io.ReadFull(d.r, chunk[:min(n-len(b), len(chunk))])
+		b = append(b, chunk[:read]...)
+		if err != nil {
+			return nil, d.unexpected(err)
+		}
+	}
+	d.crc.Write(b)
+	return b, nil
+}
+
+// Reads a length, special encodings are reported with encoded set.
+func (d *Decoder) lengthOrEncoding() (length uint64, encoded bool, err error) {
+	first, err := d.byte()
+	if err != nil {
+		return 0, false, d.unexpected(err)
+	}
+	switch first >> 6 {
+	case 0:
+		return uint64(first & 0x3F), false, nil
+	case 1:
+		next, err := d.byte()
+		if err != nil {
+			return 0, false, d.unexpected(err)
+		}
+		return uint64(first&0x3F)<<8 | uint64(next), false, nil
+	case 2:
+		switch first {
+		case 0x80:
+			b, err := d.bytes(4)
+			if err != nil {
+				return 0, false, err
+			}
+			return uint64(binary.BigEndian.Uint32(b)), false, nil
+		case 0x81:
+			b, err := d.bytes(8)
+			if err != nil {
+				return 0, false, err
+			}
+			return binary.BigEndian.Uint64(b), false, nil
+		}
+		return 0, false, fmt.Errorf("rdb: invalid length encoding 0x%x", first)
+	default:
+		return uint64(first & 0x3F), true, nil
+	}
+}
+
+func (d *Decoder) length() (uint64, error) {
+	length, encoded, err := d.lengthOrEncoding()
+	if err == nil && encoded {
+		err = fmt.Errorf("rdb: unexpected encoded length")
+	}
+	return length, err
+}
+
+// String encodings
+const (
+	encInt8  = 0
+	encInt16 = 1
+	encInt32 = 2
+	encLZF   = 3
+)
+
+func (d *Decoder) string() (string, error) {
+	length, encoded, err := d.lengthOrEncoding()
+	if err != nil {
+		return "", err
+	}
+	if !encoded {
+		b, err := d.bytes(int(length))
+		return string(b), err
+	}
+	switch length {
+	case encInt8:
+		b, err := d.bytes(1)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(int(int8(b[0]))), nil
+	case encInt16:
+		b, err := d.bytes(2)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(int(int16(binary.LittleEndian.Uint16(b)))), nil
+	case encInt32:
+		b, err := d.bytes(4)
+		if err != nil {
+			return "", err
+		}
+		return strconv.Itoa(int(int32(binary.LittleEndian.Uint32(b)))), nil
+	case encLZF:
+		compressed, err := d.length()
+		if err != nil {
+			return "", err
+		}
+		size, err := d.length()
+		if err != nil {
+			return "", err
+		}
+		b, err := d.bytes(int(compressed))
+		if err != nil {
+			return "", err
+		}
+		out, err := lzf(b, int(size))
+		return string(out), err
+	}
+	return "", fmt.Errorf("rdb: invalid string encoding %d", length)
+}
+
+// Reads a score of the first sorted set format, a string prefixed by its length.
+func (d *Decoder) float() (float64, error) {
+	length, err := d.byte()
+	if err != nil {
+		return 0, d.unexpected(err)
+	}
+	switch length {
+	case 253:
+		return math.NaN(), nil
+	case 254:
+		return math.Inf(1), nil
+	case 255:
+		return math.Inf(-1), nil
+	}
+	b, err := d.bytes(int(length))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(string(b), 64)
+}
+
+func (d *Decoder) unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'g', -1, 64)
+}
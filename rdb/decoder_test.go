@@ -0,0 +1,223 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc64"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Writes RDB files by hand, redis-server is not needed to run the tests.
+type builder struct {
+	bytes.Buffer
+}
+
+func (b *builder) length(n int) {
+	switch {
+	case n < 1<<6:
+		b.WriteByte(byte(n))
+	case n < 1<<14:
+		b.Write([]byte{0x40 | byte(n>>8), byte(n)})
+	default:
+		b.WriteByte(0x80)
+		binary.Write(b, binary.BigEndian, uint32(n))
+	}
+}
+
+func (b *builder) str(s string) {
+	b.length(len(s))
+	b.WriteString(s)
+}
+
+func (b *builder) end() []byte {
+	b.WriteByte(opEOF)
+	binary.Write(b, binary.LittleEndian, crc64.Checksum(b.Bytes(), crcTable))
+	return b.Bytes()
+}
+
+// Builds a listpack from raw entries (encoding and data).
+func lp(entries ...[]byte) string {
+	var body []byte
+	for _, e := range entries {
+		body = append(body, e...)
+		backlen := 1
+		if len(e) > 127 {
+			backlen = 2
+		}
+		body = append(body, make([]byte, backlen)...)
+	}
+	header := make([]byte, 6)
+	binary.LittleEndian.PutUint32(header, uint32(len(body)+7))
+	binary.LittleEndian.PutUint16(header[4:], uint16(len(entries)))
+	return string(append(append(header, body...), 0xFF))
+}
+
+func lpString(s string) []byte {
+	return append([]byte{0x80 | byte(len(s))}, s...)
+}
+
+func sample() *builder {
+	b := &builder{}
+	b.WriteString("REDIS0011")
+	b.WriteByte(opAux)
+	b.str("redis-ver")
+	b.str("7.2.0")
+	b.Write([]byte{opSelectDB, 0, opResizeDB, 9, 1})
+
+	b.WriteByte(typeString)
+	b.str("name")
+	b.str("John")
+
+	b.Write([]byte{opExpireTimeMs})
+	binary.Write(b, binary.LittleEndian, uint64(1700000000500))
+	b.WriteByte(typeString)
+	b.str("number")
+	b.Write([]byte{0xC1, 0x39, 0x30}) // 12345
+
+	b.WriteByte(typeString)
+	b.str("compressed")
+	b.Write([]byte{0xC3, 5, 10, 0x00, 'a', 0xE0, 0x00, 0x00})
+
+	b.WriteByte(typeList)
+	b.str("list")
+	b.length(2)
+	b.str("a")
+	b.str("b")
+
+	b.WriteByte(typeSetIntset)
+	b.str("intset")
+	b.str("\x02\x00\x00\x00\x03\x00\x00\x00\x01\x00\x02\x00\xfd\xff")
+
+	b.WriteByte(typeHashListpack)
+	b.str("config")
+	b.str(lp(lpString("url"), lpString("github.com"), lpString("timeout"), []byte{0x2A}))
+
+	b.WriteByte(typeListZiplist)
+	b.str("ziplist")
+	b.str("\x15\x00\x00\x00\x12\x00\x00\x00\x03\x00" + "\x00\x03abc" + "\x05\xfe\xfb" + "\x03\xf3" + "\xff")
+
+	b.WriteByte(typeListQuicklist2)
+	b.str("quicklist")
+	b.length(2)
+	b.length(1)
+	b.str("plain")
+	b.length(2)
+	b.str(lp(lpString("x"), []byte{0xDF, 0x9C}))
+
+	b.WriteByte(typeZSet2)
+	b.str("scores")
+	b.length(1)
+	b.str("alice")
+	binary.Write(b, binary.LittleEndian, math.Float64bits(1.5))
+
+	b.Write([]byte{opSelectDB, 1})
+	b.WriteByte(typeStreamListpacks)
+	b.str("events")
+	b.Write([]byte{0, 0, 0, 0, 0})
+
+	// aux data of a module: module id, when opcode, when, then its values
+	b.WriteByte(opModuleAux)
+	b.Write([]byte{0x80, 0, 0, 0, 1, 2, 2, 5})
+	b.str("aux")
+	b.WriteByte(0)
+
+	b.WriteByte(typeModule2)
+	b.str("module")
+	b.Write([]byte{0x80, 0, 0, 0, 1, 2, 5, 5})
+	b.str("x")
+	b.WriteByte(0)
+	return b
+}
+
+func TestDecoder(t *testing.T) {
+	decoder := NewDecoder(bytes.NewReader(sample().end()))
+	var entries []*Entry
+	for {
+		e, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if !assert.NoError(t, err) {
+			return
+		}
+		entries = append(entries, e)
+	}
+
+	assert.Equal(t, []*Entry{
+		{Key: "name", Type: TypeString, Value: "John"},
+		{Key: "number", Type: TypeString, Value: "12345", Expiry: time.Unix(1700000000, 500*int64(time.Millisecond))},
+		{Key: "compressed", Type: TypeString, Value: "aaaaaaaaaa"},
+		{Key: "list", Type: TypeList, Values: []string{"a", "b"}},
+		{Key: "intset", Type: TypeSet, Values: []string{"1", "2", "-3"}},
+		{Key: "config", Type: TypeHash, Values: []string{"url", "github.com", "timeout", "42"}},
+		{Key: "ziplist", Type: TypeList, Values: []string{"abc", "-5", "2"}},
+		{Key: "quicklist", Type: TypeList, Values: []string{"plain", "x", "-100"}},
+		{Key: "scores", Type: TypeZSet, Values: []string{"alice", "1.5"}},
+		{DB: 1, Key: "events", Type: TypeStream, Unsupported: true},
+		{DB: 1, Key: "module", Type: TypeModule, Unsupported: true},
+	}, entries)
+
+	_, err := decoder.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecoderChecksum(t *testing.T) {
+	data := sample().end()
+	data[len(data)-1] ^= 0xFF
+	assert.Equal(t, ErrChecksum, drain(data))
+
+	// a zero checksum means the checksum was disabled
+	binary.LittleEndian.PutUint64(data[len(data)-8:], 0)
+	assert.NoError(t, drain(data))
+
+	data = sample().Bytes()
+	assert.Equal(t, io.ErrUnexpectedEOF, drain(data))
+	assert.ErrorIs(t, drain(data[:len(data)-3]), io.ErrUnexpectedEOF)
+	assert.Error(t, drain([]byte("REDIT0011")))
+
+	// module aux data must give when as an unsigned integer
+	b := &builder{}
+	b.WriteString("REDIS0011")
+	b.Write([]byte{opModuleAux, 1, 5, 2, 0})
+	assert.ErrorContains(t, drain(b.end()), "when opcode 5")
+}
+
+func TestLZF(t *testing.T) {
+	out, err := lzf([]byte{2, 'a', 'b', 'c', 0xE0, 0, 2}, 12)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "abcabcabcabc", string(out))
+	}
+
+	// lengths out of reach of the compressed data
+	for _, size := range []int{-1, 1 << 40, 7*lzfMaxRatio + 1} {
+		_, err := lzf([]byte{2, 'a', 'b', 'c', 0xE0, 0, 2}, size)
+		assert.Error(t, err, size)
+	}
+	_, err = lzf([]byte{0x20, 0}, 4)
+	assert.Error(t, err)
+	_, err = lzf([]byte{5, 'a'}, 6)
+	assert.Error(t, err)
+
+	var b builder
+	b.WriteString("REDIS0011")
+	b.WriteByte(0)
+	b.str("key")
+	b.Write([]byte{0xC3, 0x01, 0x80, 0x7F, 0xFF, 0xFF, 0xFF, 'a'})
+	assert.Error(t, drain(b.Bytes()))
+}
+
+func drain(data []byte) error {
+	decoder := NewDecoder(bytes.NewReader(data))
+	for {
+		if _, err := decoder.Next(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+var errTruncated = errors.New("truncated encoded value")
+
+// Bounds checked reader over an encoded blob.
+type blob struct {
+	b   []byte
+	pos int
+}
+
+func (b *blob) next(n int) ([]byte, error) {
+	if n < 0 || b.pos+n > len(b.b) {
+		return nil, errTruncated
+	}
+	p := b.b[b.pos : b.pos+n]
+	b.pos += n
+	return p, nil
+}
+
+func (b *blob) byte() (byte, error) {
+	p, err := b.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return p[0], nil
+}
+
+// Largest expansion of LZF data, a 3 bytes back reference copying 264 bytes.
+const lzfMaxRatio = 264 / 3
+
+// Decompresses LZF data, size being the length of the decompressed data.
+func lzf(in []byte, size int) ([]byte, error) {
+	if size < 0 || size > len(in)*lzfMaxRatio {
+		return nil, fmt.Errorf("invalid LZF decompressed length %d for %d bytes", size, len(in))
+	}
+	out := make([]byte, 0, size)
+	for i := 0; i < len(in) && len(out) <= size; {
+		ctrl := int(in[i])
+		i++
+		if ctrl < 32 {
+			length := ctrl + 1
+			if i+length > len(in) {
+				return nil, errTruncated
+			}
+			out = append(out, in[i:i+length]...)
+			i += length
+			continue
+		}
+		length := ctrl >> 5
+		if length == 7 {
+			if i >= len(in) {
+				return nil, errTruncated
+			}
+			length += int(in[i])
+			i++
+		}
+		if i >= len(in) {
+			return nil, errTruncated
+		}
+		ref := len(out) - (ctrl&0x1F)<<8 - int(in[i]) - 1
+		i++
+		if ref < 0 {
+			return nil, fmt.Errorf("invalid LZF back reference")
+		}
+		// byte by byte, the reference may overlap the bytes being written
+		for j := 0; j < length+2; j++ {
+			out = append(out, out[ref+j])
+		}
+	}
+	if len(out) != size {
+		return nil, fmt.Errorf("LZF data decompressed to %d bytes, expected %d", len(out), size)
+	}
+	return out, nil
+}
+
+func ziplist(data []byte) ([]string, error) {
+	b := &blob{b: data}
+	header, err := b.next(10)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	count := int(binary.LittleEndian.Uint16(header[8:]))
+	for i := 0; ; i++ {
+		prev, err := b.byte()
+		if err != nil {
+			return nil, err
+		}
+		if prev == 0xFF {
+			break
+		}
+		if prev == 0xFE {
+			if _, err := b.next(4); err != nil {
+				return nil, err
+			}
+		}
+		enc, err := b.byte()
+		if err != nil {
+			return nil, err
+		}
+		var value string
+		switch {
+		case enc>>6 == 0:
+			value, err = b.string(int(enc & 0x3F))
+		case enc>>6 == 1:
+			var next byte
+			if next, err = b.byte(); err == nil {
+				value, err = b.string(int(enc&0x3F)<<8 | int(next))
+			}
+		case enc>>6 == 2:
+			var p []byte
+			if p, err = b.next(4); err == nil {
+				value, err = b.string(int(binary.BigEndian.Uint32(p)))
+			}
+		case enc == 0xC0:
+			value, err = b.int(2)
+		case enc == 0xD0:
+			value, err = b.int(4)
+		case enc == 0xE0:
+			value, err = b.int(8)
+		case enc == 0xF0:
+			value, err = b.int(3)
+		case enc == 0xFE:
+			value, err = b.int(1)
+		case enc >= 0xF1 && enc <= 0xFD:
+			value = strconv.Itoa(int(enc&0x0F) - 1)
+		default:
+			err = fmt.Errorf("invalid ziplist encoding 0x%x", enc)
+		}
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	// the count saturates at 65535 for large ziplists
+	if count != 0xFFFF && count != len(values) {
+		return nil, fmt.Errorf("ziplist holds %d entries, expected %d", len(values), count)
+	}
+	return values, nil
+}
+
+func listpack(data []byte) ([]string, error) {
+	b := &blob{b: data}
+	if _, err := b.next(6); err != nil {
+		return nil, err
+	}
+	var values []string
+	for {
+		start := b.pos
+		enc, err := b.byte()
+		if err != nil {
+			return nil, err
+		}
+		if enc == 0xFF {
+			break
+		}
+		var value string
+		switch {
+		case enc&0x80 == 0:
+			value = strconv.Itoa(int(enc))
+		case enc&0xC0 == 0x80:
+			value, err = b.string(int(enc & 0x3F))
+		case enc&0xE0 == 0xC0:
+			var next byte
+			if next, err = b.byte(); err == nil {
+				n := int(enc&0x1F)<<8 | int(next)
+				if n >= 1<<12 {
+					n -= 1 << 13
+				}
+				value = strconv.Itoa(n)
+			}
+		case enc&0xF0 == 0xE0:
+			var next byte
+			if next, err = b.byte(); err == nil {
+				value, err = b.string(int(enc&0x0F)<<8 | int(next))
+			}
+		case enc == 0xF0:
+			var p []byte
+			if p, err = b.next(4); err == nil {
+				value, err = b.string(int(binary.LittleEndian.Uint32(p)))
+			}
+		case enc == 0xF1:
+			value, err = b.int(2)
+		case enc == 0xF2:
+			value, err = b.int(3)
+		case enc == 0xF3:
+			value, err = b.int(4)
+		case enc == 0xF4:
+			value, err = b.int(8)
+		default:
+			err = fmt.Errorf("invalid listpack encoding 0x%x", enc)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// skip the back length, the size of the encoding and data
+		size := b.pos - start
+		backlen := 1
+		for limit := 127; size > limit && backlen < 5; limit = limit<<7 | 127 {
+			backlen++
+		}
+		if _, err := b.next(backlen); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func intset(data []byte) ([]string, error) {
+	b := &blob{b: data}
+	header, err := b.next(8)
+	if err != nil {
+		return nil, err
+	}
+	width := int(binary.LittleEndian.Uint32(header))
+	if width != 2 && width != 4 && width != 8 {
+		return nil, fmt.Errorf("invalid intset encoding %d", width)
+	}
+	count := int(binary.LittleEndian.Uint32(header[4:]))
+	if count*width != len(data)-8 {
+		return nil, errTruncated
+	}
+	values := make([]string, count)
+	for i := range values {
+		if values[i], err = b.int(width); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func zipmap(data []byte) ([]string, error) {
+	b := &blob{b: data}
+	if _, err := b.byte(); err != nil {
+		return nil, err
+	}
+	length := func() (int, bool, error) {
+		first, err := b.byte()
+		if err != nil || first == 0xFF {
+			return 0, true, err
+		}
+		if first < 254 {
+			return int(first), false, nil
+		}
+		p, err := b.next(4)
+		if err != nil {
+			return 0, false, err
+		}
+		return int(binary.LittleEndian.Uint32(p)), false, nil
+	}
+	var values []string
+	for {
+		n, end, err := length()
+		if err != nil {
+			return nil, err
+		}
+		if end {
+			return values, nil
+		}
+		key, err := b.string(n)
+		if err != nil {
+			return nil, err
+		}
+		if n, end, err = length(); err != nil || end {
+			return nil, errTruncated
+		}
+		free, err := b.byte()
+		if err != nil {
+			return nil, err
+		}
+		value, err := b.string(n)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := b.next(int(free)); err != nil {
+			return nil, err
+		}
+		values = append(values, key, value)
+	}
+}
+
+func (b *blob) string(n int) (string, error) {
+	p, err := b.next(n)
+	return string(p), err
+}
+
+// Reads a little endian signed integer of the given width.
+func (b *blob) int(width int) (string, error) {
+	p, err := b.next(width)
+	if err != nil {
+		return "", err
+	}
+	var n uint64
+	for i := width - 1; i >= 0; i-- {
+		n = n<<8 | uint64(p[i])
+	}
+	shift := 64 - 8*width
+	return strconv.FormatInt(int64(n<<shift)>>shift, 10), nil
+}
@@ -0,0 +1,67 @@
+package vedis
+
+import (
+	"bytes"
+	"time"
+)
+
+func (suite *VedisTestSuite) TestImportRDB() {
+	var data bytes.Buffer
+	data.WriteString("REDIS0011")
+	data.WriteString("\xfe\x00")
+	data.WriteString("\x00\x04name\x04John")
+	data.WriteString("\xfd\x00\xf1\x53\x65") // expires at 1700000000
+	data.WriteString("\x00\x06binary\x03\xff\x00\x01")
+	data.WriteString("\x04\x06config\x02\x03url\x0agithub.com\x07timeout\xc0\x7b")
+	data.WriteString("\x02\x06colors\x02\x03red\x04blue")
+	data.WriteString("\xfe\x01")
+	data.WriteString("\x01\x05queue\x02\x05first\x06second")
+	data.WriteString("\x03\x06scores\x01\x05alice\x032.5")
+	data.WriteString("\x0f\x06events\x00\x00\x00\x00\x00")
+	data.WriteString("\xff\x00\x00\x00\x00\x00\x00\x00\x00") // checksum disabled
+
+	report, err := suite.store.ImportRDB(&data)
+	if err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	suite.Equal(6, report.Keys)
+	suite.Equal(map[string]time.Time{"binary": time.Unix(1700000000, 0)}, report.Expires)
+	suite.Equal([]string{"events"}, report.Skipped)
+
+	if value, err := suite.store.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("John", value)
+	}
+
+	if value, _, err := suite.store.fetch("binary"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("\xff\x00\x01", value)
+	}
+
+	if hash, err := suite.store.HGetAll("config"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]string{"url", "github.com", "timeout", "123"}, hash)
+	}
+
+	if members, err := suite.store.Exec("SMEMBERS colors"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]interface{}{"red", "blue"}, members)
+	}
+
+	if value, err := suite.store.Exec("LPOP queue"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("first", value)
+	}
+
+	if score, err := suite.store.HGet("scores", "alice"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("2.5", score)
+	}
+}
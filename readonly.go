@@ -0,0 +1,124 @@
+package vedis
+
+// #include <stdlib.h>
+// #include "vedis_extra.h"
+import "C"
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+)
+
+// Commands changing the datastore, refused by read-only datastores.
+var writeCommands = map[string]bool{
+	"APPEND": true, "BEGIN": true, "COPY": true, "DECR": true, "DECRBY": true, "DEL": true,
+	"GETSET": true, "HDEL": true, "HINCRBY": true, "HINCRBYFLOAT": true, "HMSET": true, "HSET": true,
+	"HSETNX": true, "INCR": true, "INCRBY": true, "INCRBYFLOAT": true, "LPOP": true, "LPUSH": true,
+	"MOVE": true, "MSET": true, "MSETNX": true, "REMOVE": true, "SADD": true, "SET": true,
+	"SETNX": true, "SPOP": true, "SREM": true,
+}
+
+// Open the datastore stored at path for reading only, as a lookup table shipped on a read-only file system.
+// Writes fail with ErrReadOnly and no journal is ever created. Several processes may read the same file at once,
+// as long as none of them writes to it.
+// The datastore is decrypted when a key was given to Encrypt.
+//
+// See http://vedis.symisc.net/c_api/vedis_open.html
+func (v *Vedis) OpenReadOnly(path string) (bool, error) {
+	if path == ":mem:" {
+		return false, errors.New("in-memory datastores can not be opened read-only")
+	}
+	// vedis only opens the file on first access
+	if _, err := os.Stat(path); err != nil {
+		return false, err
+	}
+	v.readOnly = true
+	if v.key != nil {
+		return v.openVFS(filepath.Base(path), DirVFS(filepath.Dir(path)), C.VEDIS_EXTRA_OPEN_READONLY)
+	}
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	if status := C.vedis_extra_open(&v.ptr, cpath, C.VEDIS_EXTRA_OPEN_READONLY, nil, C.int(v.pageSize)); status != C.VEDIS_OK {
+		return false, newError(status, v.ptr)
+	}
+	if status := C.vedis_extra_register(v.ptr); status != C.VEDIS_OK {
+		return false, newError(status, v.ptr)
+	}
+	return true, nil
+}
+
+// Runs beforeWrite for every statement of command changing the datastore, args being the binary safe arguments
+// handed over with it. BEGIN fails like Begin on datastores using a Go storage engine.
+func (v *Vedis) checkWrite(command string, args ...string) error {
+	for _, tokens := range statements(command) {
+		name := strings.ToUpper(tokens[0])
+		if name == "BEGIN" && v.goEngine {
+			return ErrEngineTx
+		}
+		if writeCommands[name] {
+			// every argument is taken for a key, which is all the versions need
+			if err := v.beforeWrite(append(tokens[1:], args...)...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Reports whether command changes the datastore.
+// Vedis runs every statement of command, separated by ';', so all of them are checked.
+func isWrite(command string) bool {
+	for _, tokens := range statements(command) {
+		if writeCommands[strings.ToUpper(tokens[0])] {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the tokens of every statement of command, split the same way as vedisTokenizeInput.
+func statements(command string) [][]string {
+	var statements [][]string
+	first := true
+	for i := 0; i < len(command); {
+		c := command[i]
+		switch {
+		case c < 0xc0 && isSpace(c):
+			i++
+			continue
+		case c == ';':
+			first = true
+			i++
+			continue
+		}
+		var token string
+		if c == '"' || c == '\'' {
+			// a quoted string ends at the first quote not escaped by a backslash
+			start := i + 1
+			end := start
+			for end < len(command) && (command[end] != c || command[end-1] == '\\') {
+				end++
+			}
+			token, i = command[start:end], end+1
+		} else {
+			end := i + 1
+			for end < len(command) && (command[end] >= 0xc0 || (command[end] != ';' && !isSpace(command[end]))) {
+				end++
+			}
+			token, i = command[i:end], end
+		}
+		if first {
+			statements = append(statements, nil)
+			first = false
+		}
+		statements[len(statements)-1] = append(statements[len(statements)-1], token)
+	}
+	return statements
+}
+
+// Reports whether c is a space for SyisSpace.
+func isSpace(c byte) bool {
+	return c == ' ' || (c >= '\t' && c <= '\r')
+}
@@ -0,0 +1,73 @@
+package vedis
+
+import (
+	"os"
+	"path/filepath"
+)
+
+func (suite *VedisTestSuite) TestOpenReadOnly() {
+	dir := suite.T().TempDir()
+	path := filepath.Join(dir, "lookup.db")
+	store := New()
+	store.OpenFile(path)
+	store.Set("name", "John")
+	store.HSet("config", "url", "github.com")
+	store.Close()
+	os.Chmod(path, 0444)
+
+	_, err := New().OpenReadOnly(filepath.Join(dir, "missing.db"))
+	suite.Error(err)
+
+	reader, other := New(), New()
+	if _, err := reader.OpenReadOnly(path); err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	defer reader.Close()
+	other.OpenReadOnly(path)
+	defer other.Close()
+
+	for _, store := range []*Vedis{reader, other} {
+		if value, err := store.Get("name"); err != nil {
+			suite.Fail(err.Error())
+		} else {
+			suite.Equal("John", value)
+		}
+		if value, err := store.HGet("config", "url"); err != nil {
+			suite.Fail(err.Error())
+		} else {
+			suite.Equal("github.com", value)
+		}
+	}
+
+	_, err = reader.Set("name", "Jane")
+	suite.ErrorIs(err, ErrReadOnly)
+	_, err = reader.HSet("config", "url", "example.com")
+	suite.ErrorIs(err, ErrReadOnly)
+	_, err = reader.Exec("del name")
+	suite.ErrorIs(err, ErrReadOnly)
+	for _, command := range []string{"GET a; SET b 2", ";SET c 3", "GET a;;\n\"set\" d 4", "GET \"a;\\\"\"; DEL name"} {
+		_, err = reader.Exec(command)
+		suite.ErrorIs(err, ErrReadOnly, command)
+	}
+	for _, command := range []string{"GET \"a; SET b 2\"", "GET 'SET'; EXISTS name;"} {
+		_, err = reader.Exec(command)
+		suite.NoError(err, command)
+	}
+	_, err = reader.Begin()
+	suite.ErrorIs(err, ErrReadOnly)
+	suite.ErrorIs(NewSet(reader, "tags", StringCodec{}).Add("go"), ErrReadOnly)
+	_, err = reader.Rename("name", "first")
+	suite.ErrorIs(err, ErrReadOnly)
+
+	if value, err := reader.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("John", value)
+	}
+	for _, key := range []string{"b", "c", "d"} {
+		exists, _ := reader.Exists(key)
+		suite.False(exists, key)
+	}
+	suite.NoFileExists(path + journalSuffix)
+}
@@ -0,0 +1,137 @@
+package vedis
+
+import "fmt"
+
+// Returns the length of the string value stored at key, 0 when key does not exist.
+//
+// See http://vedis.symisc.net/cmd/strlen.html
+func (v *Vedis) StrLen(key string) (int, error) {
+	if v.compressor != nil {
+		value, _, err := v.fetch(key)
+		return len(value), err
+	}
+	return executeWithIntResult(v, "STRLEN \"%s\"", key)
+}
+
+// Parses CSV input into records of fields.
+// Records are separated by new lines outside of enclosed fields, and every record is parsed by GETCSV:
+// fields are trimmed, enclosures removed and empty fields skipped.
+//
+// See http://vedis.symisc.net/cmd/getcsv.html
+func (v *Vedis) GetCSV(input string) ([][]string, error) {
+	var records [][]string
+	for _, line := range csvLines(input) {
+		value, err := call(v, "GETCSV", line)
+		if err != nil {
+			return nil, err
+		}
+		fields, err := toStrings(toValue(value))
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, fields)
+	}
+	return records, nil
+}
+
+// Splits a string into chunks of length bytes, the last chunk may be shorter.
+//
+// See http://vedis.symisc.net/cmd/str_split.html
+func (v *Vedis) StrSplit(s string, length int) ([]string, error) {
+	if length < 1 {
+		return nil, fmt.Errorf("STR_SPLIT: invalid chunk length %d", length)
+	}
+	value, err := call(v, "STR_SPLIT", s, fmt.Sprint(length))
+	if err != nil {
+		return nil, err
+	}
+	return toStrings(toValue(value))
+}
+
+// Strips HTML tags from a string, except the allowed ones (i.e. "<b><i>").
+//
+// See http://vedis.symisc.net/cmd/strip_tag.html
+func (v *Vedis) StripTag(s string, allowed string) (string, error) {
+	args := []string{s}
+	if allowed != "" {
+		args = append(args, allowed)
+	}
+	value, err := call(v, "STRIP_TAG", args...)
+	if err != nil {
+		return "", err
+	}
+	return toString(value), nil
+}
+
+// Returns the soundex key of a string, "?000" when it holds no letter.
+//
+// See http://vedis.symisc.net/cmd/soundex.html
+func (v *Vedis) Soundex(s string) (string, error) {
+	value, err := call(v, "SOUNDEX", s)
+	if err != nil {
+		return "", err
+	}
+	return toString(value), nil
+}
+
+// Returns a human readable representation of a size in bytes, such as "1.0 GB".
+//
+// See http://vedis.symisc.net/cmd/size_fmt.html
+func (v *Vedis) SizeFmt(size int64) (string, error) {
+	value, err := call(v, "SIZE_FMT", fmt.Sprint(size))
+	if err != nil {
+		return "", err
+	}
+	return toString(value), nil
+}
+
+// Splits CSV input on the new lines found outside of double quotes, dropping blank lines.
+func csvLines(input string) []string {
+	var lines []string
+	quoted, start := false, 0
+	for i := 0; i <= len(input); i++ {
+		if i < len(input) {
+			switch input[i] {
+			case '\\':
+				if i+1 < len(input) {
+					i++
+				}
+				continue
+			case '"':
+				quoted = !quoted
+				continue
+			case '\n':
+				if quoted {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+		line := input[start:min(i, len(input))]
+		if len(line) > 0 && line[len(line)-1] == '\r' {
+			line = line[:len(line)-1]
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		start = i + 1
+	}
+	return lines
+}
+
+// Converts an array result to strings.
+func toStrings(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	array, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected result %v", value)
+	}
+	strings := make([]string, len(array))
+	for i, elem := range array {
+		strings[i] = fmt.Sprint(elem)
+	}
+	return strings, nil
+}
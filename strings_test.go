@@ -0,0 +1,69 @@
+package vedis
+
+func (suite *VedisTestSuite) TestStrLen() {
+	suite.store.Set("name", "John")
+	if length, err := suite.store.StrLen("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(4, length)
+	}
+}
+
+func (suite *VedisTestSuite) TestGetCSV() {
+	input := "id,name,quote\r\n1, John ,\"said \"\"hi\"\", then\nleft\"\n\n2,Paul,'none'\n"
+	if records, err := suite.store.GetCSV(input); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([][]string{
+			{"id", "name", "quote"},
+			{"1", "John", "said \"\"hi\"\", then\nleft"},
+			{"2", "Paul", "'none'"},
+		}, records)
+	}
+}
+
+func (suite *VedisTestSuite) TestStrSplit() {
+	if chunks, err := suite.store.StrSplit("abcdefg", 3); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]string{"abc", "def", "g"}, chunks)
+	}
+	_, err := suite.store.StrSplit("abc", 0)
+	suite.Error(err)
+}
+
+func (suite *VedisTestSuite) TestStripTag() {
+	if text, err := suite.store.StripTag(`<p>Hello <b>"World"</b></p>`, ""); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(`Hello "World"`, text)
+	}
+	if text, err := suite.store.StripTag(`<p>Hello <b>World</b></p>`, "<b>"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("Hello <b>World</b>", text)
+	}
+}
+
+func (suite *VedisTestSuite) TestSoundex() {
+	for word, key := range map[string]string{"Robert": "R163", "Rupert": "R163", "Tymczak": "T522", "": "?000"} {
+		if value, err := suite.store.Soundex(word); err != nil {
+			suite.Fail(err.Error())
+		} else {
+			suite.Equal(key, value, word)
+		}
+	}
+}
+
+func (suite *VedisTestSuite) TestSizeFmt() {
+	if size, err := suite.store.SizeFmt(1 << 30); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("1.0 GB", size)
+	}
+	if size, err := suite.store.SizeFmt(512 * 1024 * 1024); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("512.0 MB", size)
+	}
+}
@@ -0,0 +1,209 @@
+package vedis
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	textMarshaler   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshaler = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// A struct field mapped to a hash field.
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+// Returns the hash fields of a struct type.
+// Fields are named by their vedis tag, or their Go name, embedded structs are flattened and "-" skips a field:
+//
+//	type User struct {
+//		Name    string    `vedis:"name"`
+//		Email   string    `vedis:"email,omitempty"`
+//		Created time.Time `vedis:"created"`
+//		Secret  string    `vedis:"-"`
+//	}
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("vedis")
+		if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct && !isText(f.Type) {
+			for _, embedded := range structFields(f.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, structField{name: name, index: []int{i}, omitEmpty: options == "omitempty"})
+	}
+	return fields
+}
+
+// HSetStruct stores the fields of the struct pointed by v as fields of the hash stored at key.
+// Strings, integers, floats, booleans, []byte and types implementing encoding.TextMarshaler (time.Time among them) are supported.
+// Nil pointers and, with the omitempty option, zero values are not stored.
+// Values are stored binary safe, so []byte fields may hold anything.
+func (v *Vedis) HSetStruct(key string, value any) error {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("HSetStruct: %T is not a struct", value)
+	}
+	var events []Event
+	var entries []entry
+	for _, f := range structFields(rv.Type()) {
+		field := rv.FieldByIndex(f.index)
+		if f.omitEmpty && field.IsZero() {
+			continue
+		}
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				continue
+			}
+			field = field.Elem()
+		}
+		data, err := formatField(field)
+		if err != nil {
+			return fmt.Errorf("HSetStruct: field %s: %v", f.name, err)
+		}
+		entries = append(entries, entry{Key: f.name, Data: data})
+	}
+	for _, e := range entries {
+		var old string
+		if v.watched(key) {
+			found, _, _ := v.lookup(TypeHash, key, e.Key)
+			old = found.Data
+		}
+		if err := v.insert(TypeHash, key, e); err != nil {
+			return err
+		}
+		events = append(events, Event{Key: key, Field: e.Key, Op: "HMSET", Old: old, New: e.Data})
+	}
+	v.notify(events...)
+	return nil
+}
+
+// HGetStruct loads the fields of the hash stored at key into the struct pointed by dst.
+// Struct fields missing from the hash are left untouched. See HSetStruct for the supported types.
+func (v *Vedis) HGetStruct(key string, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("HGetStruct: %T is not a pointer to a struct", dst)
+	}
+	rv = rv.Elem()
+	entries, err := v.entries(TypeHash, key)
+	if err != nil {
+		return err
+	}
+	values := make(map[string]string, len(entries))
+	for _, e := range entries {
+		values[e.Key] = e.Data
+	}
+	for _, f := range structFields(rv.Type()) {
+		data, ok := values[f.name]
+		if !ok {
+			continue
+		}
+		field := rv.FieldByIndex(f.index)
+		if field.Kind() == reflect.Pointer {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			field = field.Elem()
+		}
+		if err := parseField(field, data); err != nil {
+			return fmt.Errorf("HGetStruct: field %s: %v", f.name, err)
+		}
+	}
+	return nil
+}
+
+func isText(t reflect.Type) bool {
+	return t.Implements(textMarshaler) || reflect.PointerTo(t).Implements(textUnmarshaler)
+}
+
+func formatField(field reflect.Value) (string, error) {
+	if field.Type().Implements(textMarshaler) {
+		text, err := field.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(textMarshaler) {
+		text, err := field.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch field.Kind() {
+	case reflect.String:
+		return field.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'g', -1, field.Type().Bits()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool()), nil
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.Uint8 {
+			return string(field.Bytes()), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", field.Type())
+}
+
+func parseField(field reflect.Value, data string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(data))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(data)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(data, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(data, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(data, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(data)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.Uint8 {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		field.SetBytes([]byte(data))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
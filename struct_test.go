@@ -0,0 +1,68 @@
+package vedis
+
+import (
+	"net"
+	"time"
+)
+
+type base struct {
+	ID uint64 `vedis:"id"`
+}
+
+type profile struct {
+	base
+	Name    string    `vedis:"name"`
+	Email   string    `vedis:"email,omitempty"`
+	Age     int       `vedis:"age"`
+	Score   float64   `vedis:"score"`
+	Active  bool      `vedis:"active"`
+	Created time.Time `vedis:"created"`
+	Avatar  []byte    `vedis:"avatar"`
+	Address net.IP    `vedis:"address"`
+	Nick    *string   `vedis:"nick"`
+	Secret  string    `vedis:"-"`
+}
+
+func (suite *VedisTestSuite) TestHSetStruct() {
+	created := time.Date(2015, 6, 1, 12, 30, 0, 0, time.UTC)
+	nick := "jo"
+	p := profile{
+		base:    base{ID: 7},
+		Name:    "John",
+		Age:     42,
+		Score:   9.5,
+		Active:  true,
+		Created: created,
+		Avatar:  []byte("\x89PNG\"\x00"),
+		Address: net.ParseIP("10.0.0.1"),
+		Nick:    &nick,
+		Secret:  "hidden",
+	}
+	if err := suite.store.HSetStruct("profile", &p); err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+
+	if keys, err := suite.store.HKeys("profile"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]string{"id", "name", "age", "score", "active", "created", "avatar", "address", "nick"}, keys)
+	}
+	if value, err := suite.store.HGet("profile", "created"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("2015-06-01T12:30:00Z", value)
+	}
+
+	var loaded profile
+	if err := suite.store.HGetStruct("profile", &loaded); err != nil {
+		suite.Fail(err.Error())
+	}
+	p.Secret = ""
+	suite.Equal(p, loaded)
+
+	suite.store.HSet("profile", "age", "old")
+	suite.Error(suite.store.HGetStruct("profile", &loaded))
+	suite.Error(suite.store.HGetStruct("profile", loaded))
+	suite.Error(suite.store.HSetStruct("profile", struct{ Tags []string }{}))
+}
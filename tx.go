@@ -0,0 +1,86 @@
+package vedis
+
+// Per key version counter, alive while at least one transaction is watching the key.
+type version struct {
+	value uint64
+	refs  int
+}
+
+// Tx is a check-and-set transaction, similar to Redis WATCH/MULTI/EXEC.
+// A datastore must not be used by several goroutines at once (see IsThreadSafe), so goroutines sharing one
+// serialize their calls with a lock of their own, which they release between WatchKeys and Exec
+// and hold while Exec runs: Exec then fails if another goroutine changed a watched key in between.
+type Tx struct {
+	v        *Vedis
+	versions map[string]uint64
+}
+
+// WatchKeys starts a check-and-set transaction watching the given keys.
+// Exec fails with ErrTxConflict if any of them changes before it runs, whatever the method or command writing it.
+func (v *Vedis) WatchKeys(keys ...string) *Tx {
+	tx := &Tx{v, make(map[string]uint64)}
+	v.watchers.Lock()
+	defer v.watchers.Unlock()
+	if v.watchers.versions == nil {
+		v.watchers.versions = make(map[string]*version)
+	}
+	for _, key := range keys {
+		if _, ok := tx.versions[key]; ok {
+			continue
+		}
+		ver, ok := v.watchers.versions[key]
+		if !ok {
+			ver = new(version)
+			v.watchers.versions[key] = ver
+		}
+		ver.refs++
+		tx.versions[key] = ver.value
+	}
+	return tx
+}
+
+// Exec runs fn inside a write-transaction, unless any of the watched keys was changed since WatchKeys.
+// If fn returns an error the transaction is rolled back and the error returned.
+// The keys are unwatched when Exec returns.
+func (tx *Tx) Exec(fn func() error) error {
+	defer tx.Unwatch()
+	if tx.changed() {
+		return ErrTxConflict
+	}
+	if _, err := tx.v.Begin(); err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		tx.v.Rollback()
+		return err
+	}
+	if _, err := tx.v.Commit(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Unwatch releases the watched keys without running the transaction.
+func (tx *Tx) Unwatch() {
+	tx.v.watchers.Lock()
+	defer tx.v.watchers.Unlock()
+	for key := range tx.versions {
+		if ver, ok := tx.v.watchers.versions[key]; ok {
+			if ver.refs--; ver.refs == 0 {
+				delete(tx.v.watchers.versions, key)
+			}
+		}
+	}
+	tx.versions = nil
+}
+
+func (tx *Tx) changed() bool {
+	tx.v.watchers.Lock()
+	defer tx.v.watchers.Unlock()
+	for key, value := range tx.versions {
+		if ver, ok := tx.v.watchers.versions[key]; !ok || ver.value != value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package vedis
+
+import (
+	"bytes"
+	"errors"
+)
+
+func (suite *VedisTestSuite) TestTxExec() {
+	suite.store.Set("balance", "10")
+
+	tx := suite.store.WatchKeys("balance")
+	err := tx.Exec(func() error {
+		_, err := suite.store.IncrBy("balance", 5)
+		return err
+	})
+	suite.Nil(err)
+
+	if value, err := suite.store.Get("balance"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("15", value)
+	}
+}
+
+func (suite *VedisTestSuite) TestTxConflict() {
+	suite.store.Set("balance", "10")
+
+	tx := suite.store.WatchKeys("balance")
+
+	done := make(chan bool)
+	go func() {
+		suite.store.Set("balance", "100")
+		done <- true
+	}()
+	<-done
+
+	called := false
+	err := tx.Exec(func() error {
+		called = true
+		return nil
+	})
+	suite.Equal(ErrTxConflict, err)
+	suite.False(called)
+
+	// a committed transaction also invalidates other watchers
+	first, second := suite.store.WatchKeys("balance"), suite.store.WatchKeys("balance")
+	suite.Nil(first.Exec(func() error {
+		_, err := suite.store.Incr("balance")
+		return err
+	}))
+	suite.Equal(ErrTxConflict, second.Exec(func() error { return nil }))
+}
+
+func (suite *VedisTestSuite) TestTxRollback() {
+	failure := errors.New("failure")
+
+	tx := suite.store.WatchKeys("balance")
+	err := tx.Exec(func() error {
+		return failure
+	})
+	suite.Equal(failure, err)
+
+	// rolled back changes do not conflict with other transactions
+	tx = suite.store.WatchKeys("balance")
+	suite.store.Begin()
+	suite.store.Set("balance", "1")
+	suite.store.Rollback()
+	suite.Nil(tx.Exec(func() error { return nil }))
+}
+
+func (suite *VedisTestSuite) TestTxWritePaths() {
+	suite.store.Set("balance", "10")
+	var dump bytes.Buffer
+	suite.store.Dump(&dump)
+
+	NewList(suite.store, "balance", StringCodec{}).Push("x")
+	writes := []struct {
+		name  string
+		write func()
+	}{
+		{"Exec", func() { suite.store.Exec("GET name; INCR balance") }},
+		{"Rename", func() { suite.store.Rename("balance", "other") }},
+		{"Restore", func() { suite.store.Restore(bytes.NewReader(dump.Bytes())) }},
+		{"Set.Add", func() { NewSet(suite.store, "balance", StringCodec{}).Add("x") }},
+		{"List.Pop", func() { NewList(suite.store, "balance", StringCodec{}).Pop() }},
+	}
+	for _, w := range writes {
+		tx := suite.store.WatchKeys("balance")
+		w.write()
+		suite.Equal(ErrTxConflict, tx.Exec(func() error { return nil }), w.name)
+	}
+
+	// writes to other keys do not conflict
+	tx := suite.store.WatchKeys("balance")
+	suite.store.Exec("SET name John")
+	suite.store.MSet("first", "John", "last", "Doe")
+	suite.Nil(tx.Exec(func() error { return nil }))
+}
@@ -0,0 +1,247 @@
+package vedis
+
+// Hash is a typed view of the hash stored at a key.
+// Fields and values are converted with their codecs, and stored binary safe.
+type Hash[K comparable, V any] struct {
+	v      *Vedis
+	key    string
+	fields Codec[K]
+	values Codec[V]
+}
+
+// NewHash returns a typed view of the hash stored at key.
+func NewHash[K comparable, V any](v *Vedis, key string, fields Codec[K], values Codec[V]) *Hash[K, V] {
+	return &Hash[K, V]{v: v, key: key, fields: fields, values: values}
+}
+
+// Set field of the hash to value.
+func (h *Hash[K, V]) Set(field K, value V) error {
+	f, err := encode(h.fields, h.key, field)
+	if err != nil {
+		return err
+	}
+	data, err := encode(h.values, h.key, value)
+	if err != nil {
+		return err
+	}
+	var old string
+	if h.v.watched(h.key) {
+		e, _, _ := h.v.lookup(TypeHash, h.key, f)
+		old = e.Data
+	}
+	if err := h.v.insert(TypeHash, h.key, entry{Key: f, Data: data}); err != nil {
+		return err
+	}
+	h.v.notify(Event{Key: h.key, Field: f, Op: "HSET", Old: old, New: data})
+	return nil
+}
+
+// Get the value of field, reporting whether the field exists.
+func (h *Hash[K, V]) Get(field K) (V, bool, error) {
+	var value V
+	f, err := encode(h.fields, h.key, field)
+	if err != nil {
+		return value, false, err
+	}
+	e, ok, err := h.v.lookup(TypeHash, h.key, f)
+	if !ok || err != nil {
+		return value, false, err
+	}
+	value, err = decode(h.values, h.key, e.Data)
+	return value, err == nil, err
+}
+
+// Del removes field from the hash, reporting whether it existed.
+func (h *Hash[K, V]) Del(field K) (bool, error) {
+	f, err := encode(h.fields, h.key, field)
+	if err != nil {
+		return false, err
+	}
+	var old string
+	if h.v.watched(h.key) {
+		e, _, _ := h.v.lookup(TypeHash, h.key, f)
+		old = e.Data
+	}
+	ok, err := h.v.remove(TypeHash, h.key, f)
+	if ok {
+		h.v.notify(Event{Key: h.key, Field: f, Op: "HDEL", Old: old})
+	}
+	return ok, err
+}
+
+// Len returns the number of fields of the hash.
+func (h *Hash[K, V]) Len() (int, error) {
+	return h.v.count(TypeHash, h.key), nil
+}
+
+// All returns every field of the hash with its value.
+func (h *Hash[K, V]) All() (map[K]V, error) {
+	entries, err := h.v.entries(TypeHash, h.key)
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[K]V, len(entries))
+	for _, e := range entries {
+		field, err := decode(h.fields, h.key, e.Key)
+		if err != nil {
+			return nil, err
+		}
+		if all[field], err = decode(h.values, h.key, e.Data); err != nil {
+			return nil, err
+		}
+	}
+	return all, nil
+}
+
+// Set is a typed view of the set stored at a key.
+type Set[T any] struct {
+	v     *Vedis
+	key   string
+	codec Codec[T]
+}
+
+// NewSet returns a typed view of the set stored at key.
+// Members are compared by their encoded form.
+func NewSet[T any](v *Vedis, key string, codec Codec[T]) *Set[T] {
+	return &Set[T]{v: v, key: key, codec: codec}
+}
+
+// Add members to the set, members already in the set are ignored.
+func (s *Set[T]) Add(members ...T) error {
+	for _, member := range members {
+		m, err := encode(s.codec, s.key, member)
+		if err != nil {
+			return err
+		}
+		exists := false
+		if s.v.watched(s.key) {
+			_, exists, _ = s.v.lookup(TypeSet, s.key, m)
+		}
+		if err := s.v.insert(TypeSet, s.key, entry{Key: m}); err != nil {
+			return err
+		}
+		if !exists {
+			s.v.notify(Event{Key: s.key, Op: "SADD", New: m})
+		}
+	}
+	return nil
+}
+
+// Remove member from the set, reporting whether it was in the set.
+func (s *Set[T]) Remove(member T) (bool, error) {
+	m, err := encode(s.codec, s.key, member)
+	if err != nil {
+		return false, err
+	}
+	ok, err := s.v.remove(TypeSet, s.key, m)
+	if ok {
+		s.v.notify(Event{Key: s.key, Op: "SREM", Old: m})
+	}
+	return ok, err
+}
+
+// Contains reports whether member is in the set.
+func (s *Set[T]) Contains(member T) (bool, error) {
+	m, err := encode(s.codec, s.key, member)
+	if err != nil {
+		return false, err
+	}
+	_, ok, err := s.v.lookup(TypeSet, s.key, m)
+	return ok, err
+}
+
+// Len returns the number of members of the set.
+func (s *Set[T]) Len() (int, error) {
+	return s.v.count(TypeSet, s.key), nil
+}
+
+// Members returns the members of the set in insertion order.
+func (s *Set[T]) Members() ([]T, error) {
+	entries, err := s.v.entries(TypeSet, s.key)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]T, len(entries))
+	for i, e := range entries {
+		if members[i], err = decode(s.codec, s.key, e.Key); err != nil {
+			return nil, err
+		}
+	}
+	return members, nil
+}
+
+// List is a typed view of the list stored at a key.
+type List[T any] struct {
+	v     *Vedis
+	key   string
+	codec Codec[T]
+}
+
+// NewList returns a typed view of the list stored at key.
+func NewList[T any](v *Vedis, key string, codec Codec[T]) *List[T] {
+	return &List[T]{v: v, key: key, codec: codec}
+}
+
+// Push appends values to the list, just as LPUSH.
+func (l *List[T]) Push(values ...T) error {
+	for _, value := range values {
+		data, err := encode(l.codec, l.key, value)
+		if err != nil {
+			return err
+		}
+		if err := l.v.insert(TypeList, l.key, entry{Data: data}); err != nil {
+			return err
+		}
+		l.v.notify(Event{Key: l.key, Op: "LPUSH", New: data})
+	}
+	return nil
+}
+
+// Pop removes and returns the first value of the list, just as LPOP.
+// It reports false when the list is empty.
+func (l *List[T]) Pop() (T, bool, error) {
+	var value T
+	e, ok, err := l.v.pop(TypeList, l.key)
+	if !ok || err != nil {
+		return value, false, err
+	}
+	l.v.notify(Event{Key: l.key, Op: "LPOP", Old: e.Data})
+	value, err = decode(l.codec, l.key, e.Data)
+	return value, err == nil, err
+}
+
+// Len returns the number of values of the list.
+func (l *List[T]) Len() (int, error) {
+	return l.v.count(TypeList, l.key), nil
+}
+
+// Items returns the values of the list in order.
+func (l *List[T]) Items() ([]T, error) {
+	entries, err := l.v.entries(TypeList, l.key)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]T, len(entries))
+	for i, e := range entries {
+		if items[i], err = decode(l.codec, l.key, e.Data); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+func encode[T any](codec Codec[T], key string, value T) (string, error) {
+	data, err := codec.Encode(value)
+	if err != nil {
+		return "", &CodecError{Key: key, Op: "encode", Err: err}
+	}
+	return string(data), nil
+}
+
+func decode[T any](codec Codec[T], key string, data string) (T, error) {
+	value, err := codec.Decode([]byte(data))
+	if err != nil {
+		return value, &CodecError{Key: key, Op: "decode", Err: err}
+	}
+	return value, nil
+}
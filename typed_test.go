@@ -0,0 +1,107 @@
+package vedis
+
+import (
+	"errors"
+	"time"
+)
+
+type user struct {
+	Name string
+	Age  int
+}
+
+func (suite *VedisTestSuite) TestHash() {
+	users := NewHash(suite.store, "users", StringCodec{}, JSONCodec[user]{})
+	suite.NoError(users.Set("john", user{"John", 42}))
+	suite.NoError(users.Set("paul", user{"Paul", 40}))
+
+	john, ok, err := users.Get("john")
+	suite.NoError(err)
+	suite.True(ok)
+	suite.Equal(user{"John", 42}, john)
+
+	_, ok, err = users.Get("george")
+	suite.NoError(err)
+	suite.False(ok)
+
+	// typed hashes are regular hashes
+	if value, err := suite.store.HGet("users", "paul"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(`{"Name":"Paul","Age":40}`, value)
+	}
+
+	deleted, err := users.Del("paul")
+	suite.NoError(err)
+	suite.True(deleted)
+
+	all, err := users.All()
+	suite.NoError(err)
+	suite.Equal(map[string]user{"john": {"John", 42}}, all)
+
+	suite.store.HSet("users", "broken", "{")
+	_, _, err = users.Get("broken")
+	var codecErr *CodecError
+	if suite.True(errors.As(err, &codecErr)) {
+		suite.Equal("users", codecErr.Key)
+		suite.Equal("decode", codecErr.Op)
+	}
+}
+
+func (suite *VedisTestSuite) TestSet() {
+	var ops []string
+	defer suite.store.Watch("times", func(e Event) { ops = append(ops, e.Op) })()
+	times := NewSet(suite.store, "times", BinaryCodec[time.Time]{})
+	first, second := time.Unix(1700000000, 0).UTC(), time.Unix(1800000000, 0).UTC()
+	tx := suite.store.WatchKeys("times")
+	suite.NoError(times.Add(first, second, first))
+	suite.Equal(ErrTxConflict, tx.Exec(func() error { return nil }))
+
+	count, _ := times.Len()
+	suite.Equal(2, count)
+
+	contains, err := times.Contains(second)
+	suite.NoError(err)
+	suite.True(contains)
+
+	removed, err := times.Remove(second)
+	suite.NoError(err)
+	suite.True(removed)
+
+	members, err := times.Members()
+	suite.NoError(err)
+	suite.Equal([]time.Time{first}, members)
+	suite.Equal([]string{"SADD", "SADD", "SREM"}, ops)
+
+	var codecErr *CodecError
+	suite.True(errors.As(NewSet(suite.store, "bad", BinaryCodec[int]{}).Add(1), &codecErr))
+}
+
+func (suite *VedisTestSuite) TestList() {
+	var events []Event
+	defer suite.store.Watch("queue", func(e Event) { events = append(events, e) })()
+	queue := NewList(suite.store, "queue", GobCodec[[]int]{})
+	suite.NoError(queue.Push([]int{1, 2}, []int{3}))
+
+	items, err := queue.Items()
+	suite.NoError(err)
+	suite.Equal([][]int{{1, 2}, {3}}, items)
+
+	value, ok, err := queue.Pop()
+	suite.NoError(err)
+	suite.True(ok)
+	suite.Equal([]int{1, 2}, value)
+
+	count, _ := queue.Len()
+	suite.Equal(1, count)
+	if suite.Len(events, 3) {
+		suite.Equal("LPUSH", events[1].Op)
+		suite.Equal("LPOP", events[2].Op)
+		suite.Equal(events[0].New, events[2].Old)
+	}
+
+	queue.Pop()
+	_, ok, err = queue.Pop()
+	suite.NoError(err)
+	suite.False(ok)
+}
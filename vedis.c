@@ -1 +0,0 @@
-vedis/vedis.c
\ No newline at end of file
@@ -1,12 +1,32 @@
 package vedis
 
 // #cgo CFLAGS: -Ivedis
-// #include "vedis.h"
+// #include "vedis_extra.h"
 import "C"
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"time"
+)
 
 // Vedis datastore.
 type Vedis struct {
-	ptr *C.vedis
+	ptr        *C.vedis
+	vfs        *C.vedis_vfs
+	key        []byte
+	compressor Compressor
+	readOnly   bool
+	threshold  int
+	pageSize   int
+	dir        string
+	manual     bool
+	interval   time.Duration
+	flushed    time.Time
+	progress   func(done, total int)
+	snap       *snapshot
+	goEngine   bool
+	watchers   watchers
 }
 
 // Get a new Vedis datastore.
@@ -16,27 +36,133 @@ func New() *Vedis {
 
 // Open the datastore.
 func (v *Vedis) Open() (bool, error) {
-	if status := C.vedis_open(&v.ptr, C.CString(":mem:")); status != C.VEDIS_OK {
+	return v.OpenFile(":mem:")
+}
+
+// Open the datastore stored at path.
+// If path is ":mem:" an in-memory datastore is opened instead.
+// The datastore is encrypted when a key was given to Encrypt.
+//
+// See http://vedis.symisc.net/c_api/vedis_open.html
+func (v *Vedis) OpenFile(path string) (bool, error) {
+	if path != ":mem:" {
+		v.dir = filepath.Dir(path)
+	}
+	if v.key != nil {
+		if path == ":mem:" {
+			return false, errors.New("in-memory datastores can not be encrypted")
+		}
+		return v.OpenVFS(filepath.Base(path), DirVFS(filepath.Dir(path)))
+	}
+	if status := C.vedis_extra_open(&v.ptr, C.CString(path), 0, nil, C.int(v.pageSize)); status != C.VEDIS_OK {
+		return false, newError(status, v.ptr)
+	}
+	if status := C.vedis_extra_register(v.ptr); status != C.VEDIS_OK {
 		return false, newError(status, v.ptr)
 	}
 	return true, nil
 }
 
-// Close the datastore.
+// Close the datastore. The handle is closed even when flushing the pending writes fails, all the errors being returned.
 func (v *Vedis) Close() (bool, error) {
-	if status := C.vedis_close(v.ptr); status != C.VEDIS_OK {
+	var errs []error
+	if v.manual {
+		errs = append(errs, v.Flush())
+	}
+	if status := C.vedis_extra_flush_tables(v.ptr); status != C.VEDIS_OK {
+		errs = append(errs, newError(status, v.ptr))
+	}
+	if status := C.vedis_extra_close(v.ptr); status != C.VEDIS_OK {
+		errs = append(errs, newError(status, v.ptr))
+	}
+	v.releaseVFS()
+	if err := errors.Join(errs...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Start a write-transaction.
+// Changes are not visible to watchers until the transaction is committed.
+// Fails with ErrEngineTx on datastores using a storage engine of RegisterEngine, see SetEngine.
+//
+// See http://vedis.symisc.net/c_api/vedis_begin.html
+func (v *Vedis) Begin() (bool, error) {
+	if v.readOnly {
+		return false, ErrReadOnly
+	}
+	if v.goEngine {
+		return false, ErrEngineTx
+	}
+	if status := C.vedis_begin(v.ptr); status != C.VEDIS_OK {
 		return false, newError(status, v.ptr)
 	}
+	v.beginEvents()
 	return true, nil
 }
 
+// Commit all changes made by the current write-transaction.
+//
+// See http://vedis.symisc.net/c_api/vedis_commit.html
+func (v *Vedis) Commit() (bool, error) {
+	if status := C.vedis_extra_flush_tables(v.ptr); status != C.VEDIS_OK {
+		v.endEvents(false)
+		return false, newError(status, v.ptr)
+	}
+	if status := C.vedis_commit(v.ptr); status != C.VEDIS_OK {
+		v.endEvents(false)
+		return false, newError(status, v.ptr)
+	}
+	v.endEvents(true)
+	return true, nil
+}
+
+// Rollback all changes made by the current write-transaction.
+//
+// See http://vedis.symisc.net/c_api/vedis_rollback.html
+func (v *Vedis) Rollback() (bool, error) {
+	v.endEvents(false)
+	if status := C.vedis_rollback(v.ptr); status != C.VEDIS_OK {
+		return false, newError(status, v.ptr)
+	}
+	return true, nil
+}
+
+// Execute a raw Vedis command and return its result.
+// The result is nil, int64, float64, bool, string or []interface{} according to the returned value type.
+// Changes made by Exec are not reported to watchers.
+//
+// See http://vedis.symisc.net/c_api/vedis_exec.html
+func (v *Vedis) Exec(command string) (interface{}, error) {
+	if err := execute(v, "%s", command); err != nil {
+		return nil, err
+	}
+	if result, err := result(v); err != nil {
+		return nil, err
+	} else {
+		return toValue(result), nil
+	}
+}
+
 // Set key to hold the string value.
 // If key already holds a value, it is overwritten, regardless of its type.
 // Any previous time to live associated with the key is discarded on successful SET operation.
 //
 // See http://vedis.symisc.net/cmd/set.html
 func (v *Vedis) Set(key string, value string) (bool, error) {
-	return executeWithBoolResult(v, "SET \"%s\" \"%s\"", key, value)
+	old := v.peek(key)
+	var ok bool
+	var err error
+	if v.compressor != nil {
+		err = v.store(key, value)
+		ok = err == nil
+	} else {
+		ok, err = executeWithBoolResult(v, "SET \"%s\" \"%s\"", key, value)
+	}
+	if err == nil {
+		v.notify(Event{Key: key, Op: "SET", Old: old, New: value})
+	}
+	return ok, err
 }
 
 // Set key to hold string value if key does not exist.
@@ -46,7 +172,17 @@ func (v *Vedis) Set(key string, value string) (bool, error) {
 //
 // See http://vedis.symisc.net/cmd/setnx.html
 func (v *Vedis) SetNX(key string, value string) (bool, error) {
-	return executeWithBoolResult(v, "SETNX \"%s\" \"%s\"", key, value)
+	var ok bool
+	var err error
+	if v.compressor != nil {
+		ok, err = v.storeNX(key, value)
+	} else {
+		ok, err = executeWithBoolResult(v, "SETNX \"%s\" \"%s\"", key, value)
+	}
+	if ok {
+		v.notify(Event{Key: key, Op: "SETNX", New: value})
+	}
+	return ok, err
 }
 
 // Sets the given keys to their respective values.
@@ -55,8 +191,25 @@ func (v *Vedis) SetNX(key string, value string) (bool, error) {
 //
 // See http://vedis.symisc.net/cmd/mset.html
 func (v *Vedis) MSet(kv ...string) (bool, error) {
-	command, args := massive("MSET", kv)
-	return executeWithBoolResult(v, command, args...)
+	var events []Event
+	for i := 0; i+1 < len(kv); i += 2 {
+		events = append(events, Event{Key: kv[i], Op: "MSET", Old: v.peek(kv[i]), New: kv[i+1]})
+	}
+	var ok bool
+	var err error
+	if v.compressor != nil {
+		for i := 0; i+1 < len(kv) && err == nil; i += 2 {
+			err = v.store(kv[i], kv[i+1])
+		}
+		ok = err == nil
+	} else {
+		command, args := massive("MSET", kv)
+		ok, err = executeWithBoolResult(v, command, args...)
+	}
+	if err == nil {
+		v.notify(events...)
+	}
+	return ok, err
 }
 
 // Sets the given keys to their respective values.
@@ -64,8 +217,29 @@ func (v *Vedis) MSet(kv ...string) (bool, error) {
 //
 // See http://vedis.symisc.net/cmd/msetnx.html
 func (v *Vedis) MSetNX(kv ...string) (bool, error) {
-	command, args := massive("MSETNX", kv)
-	return executeWithBoolResult(v, command, args...)
+	var events []Event
+	for i := 0; i+1 < len(kv); i += 2 {
+		if v.watched(kv[i]) {
+			if exists, _ := v.Exists(kv[i]); !exists {
+				events = append(events, Event{Key: kv[i], Op: "MSETNX", New: kv[i+1]})
+			}
+		}
+	}
+	var ok bool
+	var err error
+	if v.compressor != nil {
+		for i := 0; i+1 < len(kv) && err == nil; i += 2 {
+			_, err = v.storeNX(kv[i], kv[i+1])
+		}
+		ok = err == nil
+	} else {
+		command, args := massive("MSETNX", kv)
+		ok, err = executeWithBoolResult(v, command, args...)
+	}
+	if err == nil {
+		v.notify(events...)
+	}
+	return ok, err
 }
 
 // Check if a key already exists in the datastore.
@@ -79,14 +253,113 @@ func (v *Vedis) Exists(key string) (bool, error) {
 //
 // See http://vedis.symisc.net/cmd/copy.html
 func (v *Vedis) Copy(oldkey string, newkey string) (bool, error) {
-	return executeWithBoolResult(v, "COPY \"%s\" \"%s\"", oldkey, newkey)
+	old, value := v.peek(newkey), v.peek(oldkey)
+	ok, err := executeWithBoolResult(v, "COPY \"%s\" \"%s\"", oldkey, newkey)
+	if ok {
+		v.notify(Event{Key: newkey, Op: "COPY", Old: old, New: value})
+	}
+	return ok, err
 }
 
 // Move key values (remove old key).
 //
 // See http://vedis.symisc.net/cmd/move.html
 func (v *Vedis) Move(oldkey string, newkey string) (bool, error) {
-	return executeWithBoolResult(v, "MOVE \"%s\" \"%s\"", oldkey, newkey)
+	old, value := v.peek(newkey), v.peek(oldkey)
+	ok, err := executeWithBoolResult(v, "MOVE \"%s\" \"%s\"", oldkey, newkey)
+	if ok {
+		v.notify(Event{Key: oldkey, Op: "MOVE", Old: value}, Event{Key: newkey, Op: "MOVE", Old: old, New: value})
+	}
+	return ok, err
+}
+
+// Returns the type of the value held by key: string, hash, set, list or none when the key does not exist.
+// When the name is used by several types, string wins over hash, set and list, in that order.
+func (v *Vedis) Type(key string) (string, error) {
+	types, err := v.types(key)
+	if err != nil || len(types) == 0 {
+		return TypeNone, err
+	}
+	return types[0], nil
+}
+
+// Returns the number of keys of the datastore.
+// A name used by several types counts once for each of them.
+func (v *Vedis) DBSize() (int, error) {
+	keys, err := v.keys()
+	return len(keys), err
+}
+
+// Returns a key picked at random, or an empty string when the datastore is empty.
+func (v *Vedis) RandomKey() (string, error) {
+	keys, err := v.SampleKeys(1)
+	if err != nil || len(keys) == 0 {
+		return "", err
+	}
+	return keys[0], nil
+}
+
+// Renames oldkey to newkey, only if newkey does not exist yet, just as Redis RENAMENX.
+// Every value held by oldkey is moved, whatever its type, in a transaction rolled back when a move fails.
+// Inside a transaction started by Begin or in manual commit mode, the moves belong to the pending transaction instead,
+// and on storage engines of RegisterEngine, which have no transactions, a failed move is not undone.
+// Returns false when oldkey does not exist or newkey already exists.
+func (v *Vedis) Rename(oldkey string, newkey string) (bool, error) {
+	if v.goEngine || v.manual || v.inTx() {
+		return v.rename(oldkey, newkey)
+	}
+	// Vedis rolls back every write since the last commit, so the earlier writes are committed first
+	if _, err := v.Commit(); err != nil {
+		return false, err
+	}
+	if _, err := v.Begin(); err != nil {
+		return false, err
+	}
+	ok, err := v.rename(oldkey, newkey)
+	if err != nil {
+		v.Rollback()
+		return false, err
+	}
+	if _, err := v.Commit(); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+func (v *Vedis) rename(oldkey string, newkey string) (bool, error) {
+	types, err := v.types(oldkey)
+	if err != nil || len(types) == 0 {
+		return false, err
+	}
+	if taken, err := v.types(newkey); err != nil || len(taken) > 0 {
+		return false, err
+	}
+	var value string
+	for _, typ := range types {
+		if typ == TypeString {
+			value, _, err = v.fetch(oldkey)
+			if err == nil {
+				err = v.store(newkey, value)
+			}
+			if err == nil {
+				err = v.delete(oldkey)
+			}
+		} else {
+			var entries []entry
+			entries, err = v.entries(typ, oldkey)
+			if err == nil {
+				err = v.replace(typ, newkey, entries)
+			}
+			if err == nil {
+				err = v.replace(typ, oldkey, nil)
+			}
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	v.notify(Event{Key: oldkey, Op: "RENAME", Old: value}, Event{Key: newkey, Op: "RENAME", New: value})
+	return true, nil
 }
 
 // Get the value of key.
@@ -94,6 +367,10 @@ func (v *Vedis) Move(oldkey string, newkey string) (bool, error) {
 //
 // See http://vedis.symisc.net/cmd/get.html
 func (v *Vedis) Get(key string) (string, error) {
+	if v.compressor != nil {
+		value, _, err := v.fetch(key)
+		return value, err
+	}
 	return executeWithStringResult(v, "GET \"%s\"", key)
 }
 
@@ -103,6 +380,16 @@ func (v *Vedis) Get(key string) (string, error) {
 //
 // See http://vedis.symisc.net/cmd/mget.html
 func (v *Vedis) MGet(keys ...string) ([]string, error) {
+	if v.compressor != nil {
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			var err error
+			if values[i], err = v.Get(key); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
 	command, args := massive("MGET", keys)
 	return executeWithArrayResult(v, command, args...)
 }
@@ -112,7 +399,19 @@ func (v *Vedis) MGet(keys ...string) ([]string, error) {
 //
 // See http://vedis.symisc.net/cmd/getset.html
 func (v *Vedis) GetSet(key string, value string) (string, error) {
-	return executeWithStringResult(v, "GETSET \"%s\" \"%s\"", key, value)
+	var old string
+	var err error
+	if v.compressor != nil {
+		if old, _, err = v.fetch(key); err == nil {
+			err = v.store(key, value)
+		}
+	} else {
+		old, err = executeWithStringResult(v, "GETSET \"%s\" \"%s\"", key, value)
+	}
+	if err == nil {
+		v.notify(Event{Key: key, Op: "GETSET", Old: old, New: value})
+	}
+	return old, err
 }
 
 // Removes the specified keys.
@@ -120,7 +419,12 @@ func (v *Vedis) GetSet(key string, value string) (string, error) {
 //
 // See http://vedis.symisc.net/cmd/del.html
 func (v *Vedis) Del(key string) (int, error) {
-	return executeWithIntResult(v, "DEL \"%s\"", key)
+	old := v.peek(key)
+	count, err := executeWithIntResult(v, "DEL \"%s\"", key)
+	if count > 0 {
+		v.notify(Event{Key: key, Op: "DEL", Old: old})
+	}
+	return count, err
 }
 
 // Increments the number stored at key by one.
@@ -129,8 +433,13 @@ func (v *Vedis) Del(key string) (int, error) {
 // This operation is limited to 64 bit signed integers.
 //
 // See http://vedis.symisc.net/cmd/incr.html
-func (v *Vedis) Incr(key string) (int, error) {
-	return executeWithIntResult(v, "INCR \"%s\"", key)
+func (v *Vedis) Incr(key string) (int64, error) {
+	old := v.peek(key)
+	value, err := executeWithInt64Result(v, "INCR \"%s\"", key)
+	if err == nil {
+		v.notify(Event{Key: key, Op: "INCR", Old: old, New: strconv.FormatInt(value, 10)})
+	}
+	return value, err
 }
 
 // Increments the number stored at key by increment.
@@ -139,8 +448,13 @@ func (v *Vedis) Incr(key string) (int, error) {
 // This operation is limited to 64 bit signed integers.
 //
 // See http://vedis.symisc.net/cmd/incrby.html
-func (v *Vedis) IncrBy(key string, increment int) (int, error) {
-	return executeWithIntResult(v, "INCRBY \"%s\" %d", key, increment)
+func (v *Vedis) IncrBy(key string, increment int64) (int64, error) {
+	old := v.peek(key)
+	value, err := executeWithInt64Result(v, "INCRBY \"%s\" %d", key, increment)
+	if err == nil {
+		v.notify(Event{Key: key, Op: "INCRBY", Old: old, New: strconv.FormatInt(value, 10)})
+	}
+	return value, err
 }
 
 // Decrements the number stored at key by one.
@@ -149,8 +463,13 @@ func (v *Vedis) IncrBy(key string, increment int) (int, error) {
 // This operation is limited to 64 bit signed integers.
 //
 // See http://vedis.symisc.net/cmd/decr.html
-func (v *Vedis) Decr(key string) (int, error) {
-	return executeWithIntResult(v, "DECR \"%s\"", key)
+func (v *Vedis) Decr(key string) (int64, error) {
+	old := v.peek(key)
+	value, err := executeWithInt64Result(v, "DECR \"%s\"", key)
+	if err == nil {
+		v.notify(Event{Key: key, Op: "DECR", Old: old, New: strconv.FormatInt(value, 10)})
+	}
+	return value, err
 }
 
 // Decrements the number stored at key by decrement.
@@ -159,8 +478,26 @@ func (v *Vedis) Decr(key string) (int, error) {
 // This operation is limited to 64 bit signed integers.
 //
 // See http://vedis.symisc.net/cmd/decrby.html
-func (v *Vedis) DecrBy(key string, decrement int) (int, error) {
-	return executeWithIntResult(v, "DECRBY \"%s\" %d", key, decrement)
+func (v *Vedis) DecrBy(key string, decrement int64) (int64, error) {
+	old := v.peek(key)
+	value, err := executeWithInt64Result(v, "DECRBY \"%s\" %d", key, decrement)
+	if err == nil {
+		v.notify(Event{Key: key, Op: "DECRBY", Old: old, New: strconv.FormatInt(value, 10)})
+	}
+	return value, err
+}
+
+// Increments the floating point number stored at key by increment.
+// If the key does not exist, it is set to 0 before performing the operation.
+// An error is returned if the key contains a string that can not be represented as a floating point number.
+// The stored value uses the shortest representation of the result.
+func (v *Vedis) IncrByFloat(key string, increment float64) (float64, error) {
+	old := v.peek(key)
+	value, err := executeWithFloatResult(v, "INCRBYFLOAT \"%s\" \"%s\"", key, formatFloat(increment))
+	if err == nil {
+		v.notify(Event{Key: key, Op: "INCRBYFLOAT", Old: old, New: formatFloat(value)})
+	}
+	return value, err
 }
 
 // Sets field in the hash stored at key to value.
@@ -169,13 +506,52 @@ func (v *Vedis) DecrBy(key string, decrement int) (int, error) {
 //
 // See http://vedis.symisc.net/cmd/hset.html
 func (v *Vedis) HSet(key string, field string, value string) (bool, error) {
-	return executeWithBoolResult(v, "HSET \"%s\" \"%s\" \"%s\"", key, field, value)
+	old := v.hpeek(key, field)
+	var ok bool
+	var err error
+	if v.compressor != nil {
+		err = v.insert(TypeHash, key, entry{Key: field, Data: value})
+		ok = err == nil
+	} else {
+		ok, err = executeWithBoolResult(v, "HSET \"%s\" \"%s\" \"%s\"", key, field, value)
+	}
+	if err == nil {
+		v.notify(Event{Key: key, Field: field, Op: "HSET", Old: old, New: value})
+	}
+	return ok, err
+}
+
+// Sets field in the hash stored at key to value, only if field does not yet exist.
+// If key does not exist, a new key holding a hash is created.
+// If field already exists, this operation has no effect.
+//
+// See http://vedis.symisc.net/cmd/hsetnx.html
+func (v *Vedis) HSetNX(key string, field string, value string) (bool, error) {
+	var ok bool
+	var err error
+	if v.compressor != nil {
+		var exists bool
+		if _, exists, err = v.lookup(TypeHash, key, field); err == nil && !exists {
+			err = v.insert(TypeHash, key, entry{Key: field, Data: value})
+			ok = err == nil
+		}
+	} else {
+		ok, err = executeWithBoolResult(v, "HSETNX \"%s\" \"%s\" \"%s\"", key, field, value)
+	}
+	if ok {
+		v.notify(Event{Key: key, Field: field, Op: "HSETNX", New: value})
+	}
+	return ok, err
 }
 
 // Returns the value associated with field in the hash stored at key
 //
 // See http://vedis.symisc.net/cmd/hget.html
 func (v *Vedis) HGet(key string, field string) (string, error) {
+	if v.compressor != nil {
+		e, _, err := v.lookup(TypeHash, key, field)
+		return e.Data, err
+	}
 	return executeWithStringResult(v, "HGET \"%s\" \"%s\"", key, field)
 }
 
@@ -185,8 +561,30 @@ func (v *Vedis) HGet(key string, field string) (string, error) {
 //
 // See http://vedis.symisc.net/cmd/hdel.html
 func (v *Vedis) HDel(key string, fields ...string) (int, error) {
+	var events []Event
+	for _, field := range fields {
+		if v.watched(key) {
+			if exists, _ := v.HExists(key, field); exists {
+				events = append(events, Event{Key: key, Field: field, Op: "HDEL", Old: v.hpeek(key, field)})
+			}
+		}
+	}
 	command, args := massive("HDEL", append([]string{key}, fields...))
-	return executeWithIntResult(v, command, args...)
+	count, err := executeWithIntResult(v, command, args...)
+	if err == nil {
+		v.notify(events...)
+	}
+	return count, err
+}
+
+// Returns the length of the value associated with field in the hash stored at key.
+// If the key or the field do not exist, 0 is returned.
+func (v *Vedis) HStrLen(key string, field string) (int, error) {
+	if v.compressor != nil {
+		e, _, err := v.lookup(TypeHash, key, field)
+		return len(e.Data), err
+	}
+	return executeWithIntResult(v, "HSTRLEN \"%s\" \"%s\"", key, field)
 }
 
 // Returns the number of fields contained in the hash stored at key.
@@ -214,6 +612,14 @@ func (v *Vedis) HKeys(key string) ([]string, error) {
 //
 // See http://vedis.symisc.net/cmd/hvals.html
 func (v *Vedis) HVals(key string) ([]string, error) {
+	if v.compressor != nil {
+		entries, err := v.entries(TypeHash, key)
+		values := []string{}
+		for _, e := range entries {
+			values = append(values, e.Data)
+		}
+		return values, err
+	}
 	return executeWithArrayResult(v, "HVALS \"%s\"", key)
 }
 
@@ -223,8 +629,51 @@ func (v *Vedis) HVals(key string) ([]string, error) {
 //
 // See http://vedis.symisc.net/cmd/hmset.html
 func (v *Vedis) HMSet(key string, fv ...string) (int, error) {
-	command, args := massive("HMSET", append([]string{key}, fv...))
-	return executeWithIntResult(v, command, args...)
+	var events []Event
+	for i := 0; i+1 < len(fv); i += 2 {
+		events = append(events, Event{Key: key, Field: fv[i], Op: "HMSET", Old: v.hpeek(key, fv[i]), New: fv[i+1]})
+	}
+	var count int
+	var err error
+	if v.compressor != nil {
+		for i := 0; i+1 < len(fv) && err == nil; i += 2 {
+			if err = v.insert(TypeHash, key, entry{Key: fv[i], Data: fv[i+1]}); err == nil {
+				count++
+			}
+		}
+	} else {
+		command, args := massive("HMSET", append([]string{key}, fv...))
+		count, err = executeWithIntResult(v, command, args...)
+	}
+	if err == nil {
+		v.notify(events...)
+	}
+	return count, err
+}
+
+// Increments the number stored at field in the hash stored at key by increment.
+// If key or field do not exist, the field is set to 0 before performing the operation.
+// An error is returned if the field contains a string that can not be represented as integer.
+// This operation is limited to 64 bit signed integers.
+func (v *Vedis) HIncrBy(key string, field string, increment int64) (int64, error) {
+	old := v.hpeek(key, field)
+	value, err := executeWithInt64Result(v, "HINCRBY \"%s\" \"%s\" %d", key, field, increment)
+	if err == nil {
+		v.notify(Event{Key: key, Field: field, Op: "HINCRBY", Old: old, New: strconv.FormatInt(value, 10)})
+	}
+	return value, err
+}
+
+// Increments the floating point number stored at field in the hash stored at key by increment.
+// If key or field do not exist, the field is set to 0 before performing the operation.
+// An error is returned if the field contains a string that can not be represented as a floating point number.
+func (v *Vedis) HIncrByFloat(key string, field string, increment float64) (float64, error) {
+	old := v.hpeek(key, field)
+	value, err := executeWithFloatResult(v, "HINCRBYFLOAT \"%s\" \"%s\" \"%s\"", key, field, formatFloat(increment))
+	if err == nil {
+		v.notify(Event{Key: key, Field: field, Op: "HINCRBYFLOAT", Old: old, New: formatFloat(value)})
+	}
+	return value, err
 }
 
 // Returns the values associated with the specified fields in the hash stored at key.
@@ -233,6 +682,16 @@ func (v *Vedis) HMSet(key string, fv ...string) (int, error) {
 //
 // See http://vedis.symisc.net/cmd/hmget.html
 func (v *Vedis) HMGet(key string, fields ...string) ([]string, error) {
+	if v.compressor != nil {
+		values := make([]string, len(fields))
+		for i, field := range fields {
+			var err error
+			if values[i], err = v.HGet(key, field); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
 	command, args := massive("HMGET", append([]string{key}, fields...))
 	return executeWithArrayResult(v, command, args...)
 }
@@ -242,6 +701,14 @@ func (v *Vedis) HMGet(key string, fields ...string) ([]string, error) {
 //
 // See http://vedis.symisc.net/cmd/hgetall.html
 func (v *Vedis) HGetAll(key string) ([]string, error) {
+	if v.compressor != nil {
+		entries, err := v.entries(TypeHash, key)
+		values := []string{}
+		for _, e := range entries {
+			values = append(values, e.Key, e.Data)
+		}
+		return values, err
+	}
 	return executeWithArrayResult(v, "HGETALL \"%s\"", key)
 }
 
@@ -250,9 +717,22 @@ func (v *Vedis) HGetAll(key string) ([]string, error) {
 //
 // See http://vedis.symisc.net/cmd/append.html
 func (v *Vedis) Append(key string, value string) (int, error) {
+	old := v.peek(key)
+	if v.compressor != nil {
+		current, _, err := v.fetch(key)
+		if err != nil {
+			return 0, err
+		}
+		if err := v.store(key, current+value); err != nil {
+			return 0, err
+		}
+		v.notify(Event{Key: key, Op: "APPEND", Old: old, New: old + value})
+		return len(current) + len(value), nil
+	}
 	if err := execute(v, "APPEND \"%s\" \"%s\"", key, value); err != nil {
 		return 0, err
 	}
+	defer v.notify(Event{Key: key, Op: "APPEND", Old: old, New: old + value})
 	if result, err := result(v); err != nil {
 		return 0, err
 	} else {
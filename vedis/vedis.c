@@ -4810,9 +4810,11 @@ static int pager_read_db_header(Pager *pPager)
 			return rc;
 		}
 	}else{
-		/* Set a default page and sector size */
+		/* Set a default page and sector size, unless the page size was set when the datastore was opened */
 		pPager->iSectorSize = GetSectorSize(pPager->pfd);
-		pPager->iPageSize = vedisGetPageSize();
+		if( pPager->iPageSize < 1 ){
+			pPager->iPageSize = vedisGetPageSize();
+		}
 		SyStringInitFromBuf(&pPager->sKv,pPager->pEngine->pIo->pMethods->zName,SyStrlen(pPager->pEngine->pIo->pMethods->zName));
 		pPager->dbSize = 0;
 	}
@@ -5793,7 +5795,7 @@ VEDIS_PRIVATE int vedisPagerRegisterKvEngine(Pager *pPager,vedis_kv_methods *pMe
 	pEngine->pIo = pIo;
 	/* Invoke the init callback if avaialble */
 	if( pMethods->xInit ){
-		rc = pMethods->xInit(pEngine,vedisGetPageSize());
+		rc = pMethods->xInit(pEngine,pPager->iPageSize > 0 ? pPager->iPageSize : vedisGetPageSize());
 		if( rc != VEDIS_OK ){
 			vedisGenErrorFormat(pDb,
 				"xInit() method of the underlying KV engine '%z' failed",&pPager->sKv);
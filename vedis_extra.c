@@ -1,6 +1,1472 @@
+/*
+ * The Vedis amalgamation is compiled in the same translation unit as the
+ * helpers below, so they can reach the storage engine and table internals.
+ */
+#include <errno.h>
+#include <math.h>
+#include <pthread.h>
+#include <stdint.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include "vedis/vedis.c"
 #include "vedis_extra.h"
+#include "_cgo_export.h"
+
+/*
+ * The library is built without VEDIS_ENABLE_THREADS, so it has no mutex
+ * of its own: this one guards the global state changed or read outside
+ * of a datastore, namely the storage engines, the page size and page
+ * cache, the VFS swapped by vedis_extra_open() and the list of open
+ * datastores.
+ */
+static pthread_mutex_t vedis_extra_mutex = PTHREAD_MUTEX_INITIALIZER;
 
 void vedis_error_message(vedis *store, const char **message)
 {
     vedis_config(store, VEDIS_CONFIG_ERR_LOG, message, 0);
 }
+
+void vedis_extra_kv_name(vedis *store, const char **name)
+{
+    vedis_config(store, VEDIS_CONFIG_GET_KV_NAME, name);
+}
+
+/*
+ * Report the table header records, the only table records visible
+ * through the KV store, with their type and number of entries.
+ */
+static int vedis_extra_table_header(SyBlob *key, SyBlob *data, int *type, sxu32 *entries)
+{
+    const unsigned char *zKey = (const unsigned char *)SyBlobData(key);
+    const unsigned char *zData = (const unsigned char *)SyBlobData(data);
+    sxu16 magic;
+    if( SyBlobLength(key) < 4 || zKey[0] != 'v' || zKey[1] != 't' || zKey[2] < '1' || zKey[2] > '3' ){
+        return 0;
+    }
+    if( SyBlobLength(data) != 2 + 4 + 4 ){
+        return 0;
+    }
+    SyBigEndianUnpack16(zData, &magic);
+    if( magic != VEDIS_TABLE_MAGIC ){
+        return 0;
+    }
+    SyBigEndianUnpack32(&zData[6], entries);
+    *type = zKey[2] - '0';
+    return 1;
+}
+
+/* Report the table entry records */
+static int vedis_extra_table_entry(SyBlob *key, SyBlob *data)
+{
+    const unsigned char *zKey = (const unsigned char *)SyBlobData(key);
+    sxu16 magic;
+    if( SyBlobLength(key) < 3 || zKey[0] != 'v' || zKey[1] != 't' ){
+        return 0;
+    }
+    if( SyBlobLength(data) < 2 + 4 + 1 + 4 + 4 ){
+        return 0;
+    }
+    SyBigEndianUnpack16(SyBlobData(data), &magic);
+    return magic == VEDIS_TABLE_ENTRY_MAGIC;
+}
+
+/*
+ * Tell whether a table is loaded in memory. Loaded tables are only
+ * written back on commit, so their header record may be stale.
+ */
+static int vedis_extra_loaded(vedis *store, int type, const void *name, int name_len)
+{
+    vedis_table *table;
+    SyString string;
+    sxu32 n;
+    SyStringInitFromBuf(&string, name, name_len);
+    table = store->pTableList;
+    for( n = 0 ; n < store->nTable ; ++n ){
+        if( table->iTableType == type && SyStringCmp(&string, &table->sName, SyMemcmp) == 0 ){
+            return 1;
+        }
+        table = table->pNext;
+    }
+    return 0;
+}
+
+/*
+ * Report every non empty key of the datastore to the Go side, both the
+ * tables loaded in memory and the records found in the KV store.
+ * Header records of loaded tables are skipped, so each key is reported once.
+ */
+int vedis_extra_keys(vedis *store, uintptr_t handle)
+{
+    vedis_kv_methods *methods = vedisPagerGetKvEngine(store)->pIo->pMethods;
+    vedis_kv_cursor *cursor;
+    vedis_table *table;
+    SyBlob key, data;
+    sxu32 entries, n;
+    int rc, type;
+
+    table = store->pTableList;
+    for( n = 0 ; n < store->nTable ; ++n ){
+        if( table->nEntry > 0 ){
+            goVedisKey(handle, table->iTableType, (void *)table->sName.zString, (int)table->sName.nByte);
+        }
+        table = table->pNext;
+    }
+
+    rc = vedisInitCursor(store, &cursor);
+    if( rc != VEDIS_OK ){
+        return rc;
+    }
+    SyBlobInit(&key, &store->sMem);
+    SyBlobInit(&data, &store->sMem);
+    for( rc = methods->xFirst(cursor) ; rc == VEDIS_OK && methods->xValid(cursor) ; rc = methods->xNext(cursor) ){
+        SyBlobReset(&key);
+        SyBlobReset(&data);
+        methods->xKey(cursor, vedisDataConsumer, &key);
+        methods->xData(cursor, vedisDataConsumer, &data);
+        if( vedis_extra_table_header(&key, &data, &type, &entries) ){
+            if( entries > 0 && !vedis_extra_loaded(store, type, SyBlobDataAt(&key, 3), (int)SyBlobLength(&key) - 3) ){
+                goVedisKey(handle, type, SyBlobDataAt(&key, 3), (int)SyBlobLength(&key) - 3);
+            }
+        }else if( !vedis_extra_table_entry(&key, &data) ){
+            goVedisKey(handle, VEDIS_EXTRA_STRING, SyBlobData(&key), (int)SyBlobLength(&key));
+        }
+    }
+    SyBlobRelease(&key);
+    SyBlobRelease(&data);
+    vedisReleaseCursor(store, cursor);
+    return rc == VEDIS_DONE || rc == VEDIS_EOF || rc == VEDIS_NOTFOUND ? VEDIS_OK : rc;
+}
+
+static vedis_table * vedis_extra_table(vedis *store, int type, const void *name, int name_len, int create)
+{
+    vedis_table *table;
+    vedis_value value;
+    SyString string;
+    SyStringInitFromBuf(&string, name, name_len);
+    vedisMemObjInitFromString(store, &value, &string);
+    table = vedisFetchTable(store, &value, create, type);
+    vedisMemObjRelease(&value);
+    return table;
+}
+
+/*
+ * Report every entry of a table, in insertion order, to the Go side.
+ * List entries and set members are reported as data and key respectively.
+ */
+int vedis_extra_entries(vedis *store, int type, const void *name, int name_len, uintptr_t handle)
+{
+    vedis_table_entry *entry;
+    vedis_table *table;
+    sxu32 n;
+    table = vedis_extra_table(store, type, name, name_len, 0);
+    if( table == 0 ){
+        return VEDIS_NOTFOUND;
+    }
+    entry = table->pFirst;
+    for( n = 0 ; n < table->nEntry ; ++n ){
+        if( entry->iType == VEDIS_TABLE_ENTRY_BLOB_NODE ){
+            goVedisEntry(handle, SyBlobData(&entry->xKey.sKey), (int)SyBlobLength(&entry->xKey.sKey),
+                SyBlobData(&entry->sData), (int)SyBlobLength(&entry->sData));
+        }else{
+            goVedisEntry(handle, 0, 0, SyBlobData(&entry->sData), (int)SyBlobLength(&entry->sData));
+        }
+        entry = entry->pPrev; /* Reverse link */
+    }
+    return VEDIS_OK;
+}
+
+/*
+ * Insert an entry in a table, creating the table if needed.
+ * A NULL key appends to lists, a NULL data stores a set member.
+ */
+int vedis_extra_insert(vedis *store, int type, const void *name, int name_len, const void *key, int key_len, const void *data, int data_len)
+{
+    vedis_value key_value, data_value;
+    vedis_table *table;
+    SyString string;
+    int rc;
+    table = vedis_extra_table(store, type, name, name_len, 1);
+    if( table == 0 ){
+        return VEDIS_NOMEM;
+    }
+    SyStringInitFromBuf(&string, key, key_len);
+    vedisMemObjInitFromString(store, &key_value, &string);
+    SyStringInitFromBuf(&string, data, data_len);
+    vedisMemObjInitFromString(store, &data_value, &string);
+    rc = vedisTableInsertRecord(table, key ? &key_value : 0, data ? &data_value : 0);
+    vedisMemObjRelease(&key_value);
+    vedisMemObjRelease(&data_value);
+    return rc;
+}
+
+/* Remove every entry of a table */
+int vedis_extra_clear(vedis *store, int type, const void *name, int name_len)
+{
+    vedis_table *table;
+    int rc;
+    table = vedis_extra_table(store, type, name, name_len, 0);
+    if( table == 0 ){
+        return VEDIS_OK;
+    }
+    while( table->nEntry > 0 ){
+        rc = VedisRemoveTableEntry(table, table->pFirst);
+        if( rc != VEDIS_OK ){
+            return rc;
+        }
+    }
+    return VEDIS_OK;
+}
+
+static vedis_table_entry * vedis_extra_lookup(vedis *store, vedis_table *table, const void *key, int key_len)
+{
+    vedis_table_entry *entry;
+    vedis_value value;
+    SyString string;
+    SyStringInitFromBuf(&string, key, key_len);
+    vedisMemObjInitFromString(store, &value, &string);
+    entry = vedisTableGetRecord(table, &value);
+    vedisMemObjRelease(&value);
+    return entry;
+}
+
+/* Report a single hash field or set member to the Go side */
+int vedis_extra_fetch(vedis *store, int type, const void *name, int name_len, const void *key, int key_len, uintptr_t handle)
+{
+    vedis_table_entry *entry;
+    vedis_table *table;
+    table = vedis_extra_table(store, type, name, name_len, 0);
+    if( table == 0 || (entry = vedis_extra_lookup(store, table, key, key_len)) == 0 ){
+        return VEDIS_NOTFOUND;
+    }
+    goVedisEntry(handle, SyBlobData(&entry->xKey.sKey), (int)SyBlobLength(&entry->xKey.sKey),
+        SyBlobData(&entry->sData), (int)SyBlobLength(&entry->sData));
+    return VEDIS_OK;
+}
+
+/* Remove a single hash field or set member */
+int vedis_extra_remove(vedis *store, int type, const void *name, int name_len, const void *key, int key_len)
+{
+    vedis_table_entry *entry;
+    vedis_table *table;
+    table = vedis_extra_table(store, type, name, name_len, 0);
+    if( table == 0 || (entry = vedis_extra_lookup(store, table, key, key_len)) == 0 ){
+        return VEDIS_NOTFOUND;
+    }
+    return VedisRemoveTableEntry(table, entry);
+}
+
+/* Remove the first entry of a table, reporting it to the Go side */
+int vedis_extra_pop(vedis *store, int type, const void *name, int name_len, uintptr_t handle)
+{
+    vedis_table_entry *entry;
+    vedis_table *table;
+    table = vedis_extra_table(store, type, name, name_len, 0);
+    if( table == 0 || table->nEntry < 1 ){
+        return VEDIS_NOTFOUND;
+    }
+    entry = table->pFirst;
+    goVedisEntry(handle, 0, 0, SyBlobData(&entry->sData), (int)SyBlobLength(&entry->sData));
+    return VedisRemoveTableEntry(table, entry);
+}
+
+/* Number of entries of a table */
+int vedis_extra_count(vedis *store, int type, const void *name, int name_len)
+{
+    vedis_table *table;
+    table = vedis_extra_table(store, type, name, name_len, 0);
+    return table == 0 ? 0 : (int)table->nEntry;
+}
+
+/*
+ * Parse a stored number, the whole value must be a valid number.
+ * An empty value, like a missing one, counts as zero.
+ */
+static int vedis_extra_parse_int64(const char *data, int len, vedis_int64 *value)
+{
+    char buf[32], *end;
+    if( len == 0 ){
+        *value = 0;
+        return 1;
+    }
+    if( len >= (int)sizeof(buf) ){
+        return 0;
+    }
+    memcpy(buf, data, len);
+    buf[len] = 0;
+    errno = 0;
+    *value = strtoll(buf, &end, 10);
+    return errno == 0 && *end == 0;
+}
+
+static int vedis_extra_parse_double(const char *data, int len, double *value)
+{
+    char buf[64], *end;
+    if( len == 0 ){
+        *value = 0;
+        return 1;
+    }
+    if( len >= (int)sizeof(buf) ){
+        return 0;
+    }
+    memcpy(buf, data, len);
+    buf[len] = 0;
+    errno = 0;
+    *value = strtod(buf, &end);
+    return errno == 0 && *end == 0 && isfinite(*value);
+}
+
+/* Format a double with the shortest representation reading back to the same value */
+static int vedis_extra_format_double(double value, char *buf, int size)
+{
+    int precision, len = 0;
+    for( precision = 15 ; precision <= 17 ; ++precision ){
+        len = snprintf(buf, size, "%.*g", precision, value);
+        if( strtod(buf, 0) == value ){
+            break;
+        }
+    }
+    return len;
+}
+
+/* Store a string value under key, in a hash when field is not NULL */
+static int vedis_extra_store(vedis_context *ctx, vedis_table *table, vedis_value *key, vedis_value *field, const char *data, int len)
+{
+    vedis_value *value;
+    int rc;
+    value = vedis_context_new_scalar(ctx);
+    if( value == 0 ){
+        vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "Out of memory");
+        return VEDIS_ABORT;
+    }
+    vedis_value_string(value, data, len);
+    if( table ){
+        rc = vedisTableInsertRecord(table, field, value);
+    }else{
+        rc = VedisStoreValue(ctx, key, value);
+    }
+    vedis_context_release_value(ctx, value);
+    return rc;
+}
+
+/*
+ * Increment the number stored at key, or at field of the hash stored at key,
+ * by increment or by one when increment is NULL.
+ * Integers are limited to 64 bit signed integers and may not overflow.
+ */
+static int vedis_extra_increment(vedis_context *ctx, vedis_value *key, vedis_value *field, vedis_value *increment, int decrement, int real)
+{
+    vedis *store = (vedis *)vedis_context_user_data(ctx);
+    vedis_table *table = 0;
+    vedis_table_entry *entry;
+    const char *data = "";
+    char buf[64];
+    int len = 0, arg_len, rc;
+    SyBlob *worker;
+
+    if( field ){
+        table = vedisFetchTable(store, key, 1, VEDIS_TABLE_HASH);
+        if( table == 0 ){
+            vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "Out of memory");
+            return VEDIS_ABORT;
+        }
+        entry = vedisTableGetRecord(table, field);
+        if( entry ){
+            data = (const char *)SyBlobData(&entry->sData);
+            len = (int)SyBlobLength(&entry->sData);
+        }
+    }else{
+        worker = VedisContextWorkingBuffer(ctx);
+        SyBlobReset(worker);
+        if( vedisFetchValue(ctx, key, worker) == VEDIS_OK ){
+            data = (const char *)SyBlobData(worker);
+            len = (int)SyBlobLength(worker);
+        }
+    }
+
+    if( real ){
+        double value, by;
+        const char *arg = vedis_value_to_string(increment, &arg_len);
+        if( !vedis_extra_parse_double(arg, arg_len, &by) ){
+            vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "increment is not a valid float");
+            return VEDIS_ABORT;
+        }
+        if( !vedis_extra_parse_double(data, len, &value) ){
+            vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "value is not a valid float");
+            return VEDIS_ABORT;
+        }
+        value += by;
+        if( !isfinite(value) ){
+            vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "increment would produce NaN or Infinity");
+            return VEDIS_ABORT;
+        }
+        len = vedis_extra_format_double(value, buf, sizeof(buf));
+        vedis_result_double(ctx, value);
+    }else{
+        vedis_int64 value, by = 1;
+        if( increment ){
+            const char *arg = vedis_value_to_string(increment, &arg_len);
+            if( !vedis_extra_parse_int64(arg, arg_len, &by) ){
+                vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "increment is not an integer or out of range");
+                return VEDIS_ABORT;
+            }
+        }
+        if( !vedis_extra_parse_int64(data, len, &value) ){
+            vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "value is not an integer or out of range");
+            return VEDIS_ABORT;
+        }
+        if( decrement ){
+            if( by == INT64_MIN ){
+                vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "increment or decrement would overflow");
+                return VEDIS_ABORT;
+            }
+            by = -by;
+        }
+        if( (by > 0 && value > INT64_MAX - by) || (by < 0 && value < INT64_MIN - by) ){
+            vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "increment or decrement would overflow");
+            return VEDIS_ABORT;
+        }
+        value += by;
+        len = snprintf(buf, sizeof(buf), "%lld", (long long)value);
+        vedis_result_int64(ctx, value);
+    }
+    rc = vedis_extra_store(ctx, table, key, field, buf, len);
+    return rc == VEDIS_OK ? VEDIS_OK : VEDIS_ABORT;
+}
+
+/*
+ *  Command:   INCR key
+ *  Command:   DECR key
+ * Replace the engine commands, which silently treat non integer values as zero.
+ */
+static int vedis_extra_cmd_incr(vedis_context *ctx, int argc, vedis_value **argv)
+{
+    if( argc < 1 ){
+        vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "Missing key");
+        vedis_result_int(ctx, 0);
+        return VEDIS_OK;
+    }
+    return vedis_extra_increment(ctx, argv[0], 0, 0, 0, 0);
+}
+
+static int vedis_extra_cmd_decr(vedis_context *ctx, int argc, vedis_value **argv)
+{
+    if( argc < 1 ){
+        vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "Missing key");
+        vedis_result_int(ctx, 0);
+        return VEDIS_OK;
+    }
+    return vedis_extra_increment(ctx, argv[0], 0, 0, 1, 0);
+}
+
+/*
+ *  Command:   INCRBY key increment
+ *  Command:   DECRBY key decrement
+ * Replace the engine commands, which truncate the increment to a C int.
+ */
+static int vedis_extra_cmd_incrby(vedis_context *ctx, int argc, vedis_value **argv)
+{
+    if( argc < 2 ){
+        vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "Missing key/increment");
+        vedis_result_int(ctx, 0);
+        return VEDIS_OK;
+    }
+    return vedis_extra_increment(ctx, argv[0], 0, argv[1], 0, 0);
+}
+
+static int vedis_extra_cmd_decrby(vedis_context *ctx, int argc, vedis_value **argv)
+{
+    if( argc < 2 ){
+        vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "Missing key/decrement");
+        vedis_result_int(ctx, 0);
+        return VEDIS_OK;
+    }
+    return vedis_extra_increment(ctx, argv[0], 0, argv[1], 1, 0);
+}
+
+/*
+ *  Command:   INCRBYFLOAT key increment
+ * Return:
+ *   the value of key after the increment
+ */
+static int vedis_extra_cmd_incrbyfloat(vedis_context *ctx, int argc, vedis_value **argv)
+{
+    if( argc < 2 ){
+        vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "Missing key/increment");
+        vedis_result_double(ctx, 0);
+        return VEDIS_OK;
+    }
+    return vedis_extra_increment(ctx, argv[0], 0, argv[1], 0, 1);
+}
+
+/*
+ *  Command:   HINCRBY key field increment
+ * Return:
+ *   the value of the field after the increment
+ */
+static int vedis_extra_cmd_hincrby(vedis_context *ctx, int argc, vedis_value **argv)
+{
+    if( argc < 3 ){
+        vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "Missing key/field/increment");
+        vedis_result_int(ctx, 0);
+        return VEDIS_OK;
+    }
+    return vedis_extra_increment(ctx, argv[0], argv[1], argv[2], 0, 0);
+}
+
+/*
+ *  Command:   HINCRBYFLOAT key field increment
+ * Return:
+ *   the value of the field after the increment
+ */
+static int vedis_extra_cmd_hincrbyfloat(vedis_context *ctx, int argc, vedis_value **argv)
+{
+    if( argc < 3 ){
+        vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "Missing key/field/increment");
+        vedis_result_double(ctx, 0);
+        return VEDIS_OK;
+    }
+    return vedis_extra_increment(ctx, argv[0], argv[1], argv[2], 0, 1);
+}
+
+/*
+ *  Command:   HSTRLEN key field
+ * Return:
+ *   the length of the value of the field, 0 when key or field do not exist
+ */
+static int vedis_extra_cmd_hstrlen(vedis_context *ctx, int argc, vedis_value **argv)
+{
+    vedis *store = (vedis *)vedis_context_user_data(ctx);
+    vedis_table_entry *entry;
+    vedis_table *table;
+    if( argc < 2 ){
+        vedis_context_throw_error(ctx, VEDIS_CTX_ERR, "Missing key/field");
+        vedis_result_int(ctx, 0);
+        return VEDIS_OK;
+    }
+    table = vedisFetchTable(store, argv[0], 0, VEDIS_TABLE_HASH);
+    entry = table ? vedisTableGetRecord(table, argv[1]) : 0;
+    vedis_result_int64(ctx, entry ? (vedis_int64)SyBlobLength(&entry->sData) : 0);
+    return VEDIS_OK;
+}
+
+/*
+ * Run a command with the given arguments, bypassing the command lexer so the
+ * arguments are passed as they are, binary safe. The result is available
+ * through vedis_exec_result() as with vedis_exec().
+ */
+int vedis_extra_call(vedis *store, const char *name, int argc, const char **args, const int *lens)
+{
+    vedis_value **values;
+    vedis_context ctx;
+    vedis_cmd *cmd;
+    SyString string;
+    int n, rc;
+
+    SyStringInitFromBuf(&string, name, SyStrlen(name));
+    cmd = vedisFetchCommand(store, &string);
+    if( cmd == 0 ){
+        vedisGenErrorFormat(store, "Unknown Vedis command: '%z'", &string);
+        return VEDIS_UNKNOWN;
+    }
+    values = (vedis_value **)SyMemBackendAlloc(&store->sMem, sizeof(vedis_value *) * (argc + 1));
+    if( values == 0 ){
+        return VEDIS_NOMEM;
+    }
+    for( n = 0 ; n < argc ; ++n ){
+        values[n] = vedisNewObjectValue(store, 0);
+        if( values[n] == 0 ){
+            break;
+        }
+        SyStringInitFromBuf(&string, args[n], lens[n]);
+        vedisMemObjInitFromString(store, values[n], &string);
+    }
+    if( n < argc ){
+        rc = VEDIS_NOMEM;
+    }else{
+        vedisInitContext(&ctx, store, cmd);
+        rc = cmd->xCmd(&ctx, argc, values);
+        if( rc == VEDIS_ABORT ){
+            vedisGenErrorFormat(store, "Vedis command '%z' request an operation abort", &cmd->sName);
+        }else{
+            rc = VEDIS_OK;
+        }
+        vedisReleaseContext(&ctx);
+    }
+    while( n-- > 0 ){
+        vedisObjectValueDestroy(store, values[n]);
+    }
+    SyMemBackendFree(&store->sMem, values);
+    return rc;
+}
+
+/* Install the extra commands on a freshly opened datastore */
+int vedis_extra_register(vedis *store)
+{
+    static const struct {
+        const char *name;
+        ProcVedisCmd cmd;
+    } commands[] = {
+        { "INCR",         vedis_extra_cmd_incr },
+        { "DECR",         vedis_extra_cmd_decr },
+        { "INCRBY",       vedis_extra_cmd_incrby },
+        { "DECRBY",       vedis_extra_cmd_decrby },
+        { "INCRBYFLOAT",  vedis_extra_cmd_incrbyfloat },
+        { "HINCRBY",      vedis_extra_cmd_hincrby },
+        { "HINCRBYFLOAT", vedis_extra_cmd_hincrbyfloat },
+        { "HSTRLEN",      vedis_extra_cmd_hstrlen },
+    };
+    size_t n;
+    int rc;
+    for( n = 0 ; n < sizeof(commands) / sizeof(commands[0]) ; ++n ){
+        rc = vedis_register_command(store, commands[n].name, commands[n].cmd, store);
+        if( rc != VEDIS_OK ){
+            return rc;
+        }
+    }
+    return VEDIS_OK;
+}
+
+/*
+ * Storage engines implemented in Go. Every method forwards to the Go side
+ * through the cgo handle of the engine or cursor instance, the handle of the
+ * factory creating engine instances lives next to the method table.
+ * There is no xOpen method: the pager only calls it when it reads pages,
+ * so the Go engine is opened by xInit instead.
+ */
+typedef struct vedis_extra_methods {
+    vedis_kv_methods methods; /* Must be first */
+    uintptr_t factory;
+} vedis_extra_methods;
+
+typedef struct vedis_extra_engine {
+    vedis_kv_engine base; /* Must be first */
+    uintptr_t handle;
+} vedis_extra_engine;
+
+typedef struct vedis_extra_cursor {
+    vedis_kv_cursor base; /* Must be first */
+    uintptr_t handle;
+} vedis_extra_cursor;
+
+#define ENGINE_HANDLE(engine) (((vedis_extra_engine *)(engine))->handle)
+#define CURSOR_HANDLE(cursor) (((vedis_extra_cursor *)(cursor))->handle)
+
+static int vedis_extra_engine_init(vedis_kv_engine *engine, int page_size)
+{
+    const vedis_extra_methods *methods = (const vedis_extra_methods *)engine->pIo->pMethods;
+    SXUNUSED(page_size);
+    ENGINE_HANDLE(engine) = goEngineInit(methods->factory);
+    return ENGINE_HANDLE(engine) ? VEDIS_OK : VEDIS_IOERR;
+}
+
+static void vedis_extra_engine_release(vedis_kv_engine *engine)
+{
+    if( ENGINE_HANDLE(engine) ){
+        goEngineRelease(ENGINE_HANDLE(engine));
+        ENGINE_HANDLE(engine) = 0;
+    }
+}
+
+static int vedis_extra_engine_replace(vedis_kv_engine *engine, const void *key, int key_len, const void *data, vedis_int64 data_len)
+{
+    return goEngineWrite(ENGINE_HANDLE(engine), (void *)key, key_len, (void *)data, data_len, 0);
+}
+
+static int vedis_extra_engine_append(vedis_kv_engine *engine, const void *key, int key_len, const void *data, vedis_int64 data_len)
+{
+    return goEngineWrite(ENGINE_HANDLE(engine), (void *)key, key_len, (void *)data, data_len, 1);
+}
+
+static void vedis_extra_cursor_init(vedis_kv_cursor *cursor)
+{
+    CURSOR_HANDLE(cursor) = goCursorInit(ENGINE_HANDLE(cursor->pStore));
+}
+
+static int vedis_extra_cursor_seek(vedis_kv_cursor *cursor, const void *key, int key_len, int pos)
+{
+    return goCursorSeek(CURSOR_HANDLE(cursor), (void *)key, key_len, pos);
+}
+
+static int vedis_extra_cursor_first(vedis_kv_cursor *cursor)
+{
+    return goCursorMove(CURSOR_HANDLE(cursor), VEDIS_EXTRA_FIRST);
+}
+
+static int vedis_extra_cursor_last(vedis_kv_cursor *cursor)
+{
+    return goCursorMove(CURSOR_HANDLE(cursor), VEDIS_EXTRA_LAST);
+}
+
+static int vedis_extra_cursor_next(vedis_kv_cursor *cursor)
+{
+    return goCursorMove(CURSOR_HANDLE(cursor), VEDIS_EXTRA_NEXT);
+}
+
+static int vedis_extra_cursor_prev(vedis_kv_cursor *cursor)
+{
+    return goCursorMove(CURSOR_HANDLE(cursor), VEDIS_EXTRA_PREV);
+}
+
+static int vedis_extra_cursor_valid(vedis_kv_cursor *cursor)
+{
+    return goCursorValid(CURSOR_HANDLE(cursor));
+}
+
+static int vedis_extra_cursor_delete(vedis_kv_cursor *cursor)
+{
+    return goCursorDelete(CURSOR_HANDLE(cursor));
+}
+
+static int vedis_extra_cursor_key_length(vedis_kv_cursor *cursor, int *len)
+{
+    vedis_int64 n;
+    int rc = goCursorLength(CURSOR_HANDLE(cursor), 1, &n);
+    *len = (int)n;
+    return rc;
+}
+
+static int vedis_extra_cursor_data_length(vedis_kv_cursor *cursor, vedis_int64 *len)
+{
+    return goCursorLength(CURSOR_HANDLE(cursor), 0, len);
+}
+
+/* Copy the key or data of the current record and hand it to the consumer */
+static int vedis_extra_cursor_consume(vedis_kv_cursor *cursor, int key, int (*consumer)(const void *, unsigned int, void *), void *user_data)
+{
+    void *buf = 0;
+    vedis_int64 len = 0;
+    int rc;
+    rc = goCursorCopy(CURSOR_HANDLE(cursor), key, &buf, &len);
+    if( rc == VEDIS_OK ){
+        rc = consumer(buf, (unsigned int)len, user_data);
+    }
+    free(buf);
+    return rc;
+}
+
+static int vedis_extra_cursor_key(vedis_kv_cursor *cursor, int (*consumer)(const void *, unsigned int, void *), void *user_data)
+{
+    return vedis_extra_cursor_consume(cursor, 1, consumer, user_data);
+}
+
+static int vedis_extra_cursor_data(vedis_kv_cursor *cursor, int (*consumer)(const void *, unsigned int, void *), void *user_data)
+{
+    return vedis_extra_cursor_consume(cursor, 0, consumer, user_data);
+}
+
+static void vedis_extra_cursor_reset(vedis_kv_cursor *cursor)
+{
+    goCursorMove(CURSOR_HANDLE(cursor), VEDIS_EXTRA_FIRST);
+}
+
+static void vedis_extra_cursor_release(vedis_kv_cursor *cursor)
+{
+    if( CURSOR_HANDLE(cursor) ){
+        goCursorRelease(CURSOR_HANDLE(cursor));
+        CURSOR_HANDLE(cursor) = 0;
+    }
+}
+
+/*
+ * Install a storage engine implemented in Go under the given name.
+ * The method table is never released, installed engines live as long as
+ * the library.
+ */
+int vedis_extra_register_engine(const char *name, uintptr_t factory)
+{
+    vedis_extra_methods *methods;
+    vedis_kv_methods *installed;
+    int rc;
+    pthread_mutex_lock(&vedis_extra_mutex);
+    rc = vedis_lib_init();
+    if( rc != VEDIS_OK ){
+        pthread_mutex_unlock(&vedis_extra_mutex);
+        return rc;
+    }
+    if( vedisFindKVStore(name, SyStrlen(name)) ){
+        pthread_mutex_unlock(&vedis_extra_mutex);
+        return VEDIS_EXISTS;
+    }
+    methods = (vedis_extra_methods *)calloc(1, sizeof(vedis_extra_methods));
+    if( methods == 0 ){
+        pthread_mutex_unlock(&vedis_extra_mutex);
+        return VEDIS_NOMEM;
+    }
+    methods->methods.zName = strdup(name);
+    methods->methods.szKv = sizeof(vedis_extra_engine);
+    methods->methods.szCursor = sizeof(vedis_extra_cursor);
+    methods->methods.iVersion = 1;
+    methods->methods.xInit = vedis_extra_engine_init;
+    methods->methods.xRelease = vedis_extra_engine_release;
+    methods->methods.xReplace = vedis_extra_engine_replace;
+    methods->methods.xAppend = vedis_extra_engine_append;
+    methods->methods.xCursorInit = vedis_extra_cursor_init;
+    methods->methods.xSeek = vedis_extra_cursor_seek;
+    methods->methods.xFirst = vedis_extra_cursor_first;
+    methods->methods.xLast = vedis_extra_cursor_last;
+    methods->methods.xValid = vedis_extra_cursor_valid;
+    methods->methods.xNext = vedis_extra_cursor_next;
+    methods->methods.xPrev = vedis_extra_cursor_prev;
+    methods->methods.xDelete = vedis_extra_cursor_delete;
+    methods->methods.xKeyLength = vedis_extra_cursor_key_length;
+    methods->methods.xKey = vedis_extra_cursor_key;
+    methods->methods.xDataLength = vedis_extra_cursor_data_length;
+    methods->methods.xData = vedis_extra_cursor_data;
+    methods->methods.xReset = vedis_extra_cursor_reset;
+    methods->methods.xCursorRelease = vedis_extra_cursor_release;
+    methods->factory = factory;
+    /* vedis_lib_config() is locked once the library is initialized */
+    installed = &methods->methods;
+    rc = SySetPut(&sVedisMPGlobal.kv_storage, (const void *)&installed);
+    pthread_mutex_unlock(&vedis_extra_mutex);
+    return rc;
+}
+
+/*
+ * Switch a freshly opened datastore to another storage engine,
+ * the missing VEDIS_CONFIG_KV_ENGINE option of vedis_config().
+ */
+int vedis_extra_kv_engine(vedis *store, const char *name)
+{
+    vedis_kv_methods *methods;
+    int rc;
+    pthread_mutex_lock(&vedis_extra_mutex);
+    methods = vedisFindKVStore(name, SyStrlen(name));
+    pthread_mutex_unlock(&vedis_extra_mutex);
+    if( methods == 0 ){
+        vedisGenErrorFormat(store, "No such Key/Value storage engine '%s'", name);
+        return VEDIS_NOTFOUND;
+    }
+    rc = vedisPagerRegisterKvEngine(store->pPager, methods);
+    if( rc == VEDIS_OK ){
+        SyStringInitFromBuf(&store->pPager->sKv, methods->zName, SyStrlen(methods->zName));
+    }
+    return rc;
+}
+
+/*
+ * Write the hashes, sets and lists back to a Go storage engine. The pager
+ * only does it on commit when it holds dirty pages, which a Go engine never
+ * produces, so this must be called before every commit and close.
+ */
+int vedis_extra_flush_tables(vedis *store)
+{
+    vedis_kv_engine *engine = vedisPagerGetKvEngine(store);
+    if( engine == 0 || engine->pIo->pMethods->xInit != vedis_extra_engine_init ){
+        return VEDIS_OK;
+    }
+    return vedisOnCommit(store);
+}
+
+/*
+ * Virtual file systems implemented in Go. Files are locked by the Go side
+ * when they can be, never memory mapped, time, sleep and temporary
+ * directory come from the builtin VFS of the platform.
+ */
+typedef struct vedis_extra_vfs {
+    vedis_vfs base; /* Must be first */
+    uintptr_t handle;
+} vedis_extra_vfs;
+
+typedef struct vedis_extra_file {
+    vedis_file base; /* Must be first */
+    uintptr_t handle;
+} vedis_extra_file;
+
+#define VFS_HANDLE(vfs)   (((vedis_extra_vfs *)(vfs))->handle)
+#define FILE_HANDLE(file) (((vedis_extra_file *)(file))->handle)
+
+static int vedis_extra_file_close(vedis_file *file)
+{
+    return goFileClose(FILE_HANDLE(file));
+}
+
+static int vedis_extra_file_read(vedis_file *file, void *buf, vedis_int64 amount, vedis_int64 offset)
+{
+    return goFileRead(FILE_HANDLE(file), buf, amount, offset);
+}
+
+static int vedis_extra_file_write(vedis_file *file, const void *buf, vedis_int64 amount, vedis_int64 offset)
+{
+    return goFileWrite(FILE_HANDLE(file), (void *)buf, amount, offset);
+}
+
+static int vedis_extra_file_truncate(vedis_file *file, vedis_int64 size)
+{
+    return goFileTruncate(FILE_HANDLE(file), size);
+}
+
+static int vedis_extra_file_sync(vedis_file *file, int flags)
+{
+    SXUNUSED(flags);
+    return goFileSync(FILE_HANDLE(file));
+}
+
+static int vedis_extra_file_size(vedis_file *file, vedis_int64 *size)
+{
+    return goFileSize(FILE_HANDLE(file), size);
+}
+
+static int vedis_extra_file_lock(vedis_file *file, int level)
+{
+    return goFileLock(FILE_HANDLE(file), level, 1);
+}
+
+static int vedis_extra_file_unlock(vedis_file *file, int level)
+{
+    return goFileLock(FILE_HANDLE(file), level, 0);
+}
+
+/*
+ * The Go side locks the whole file exclusively from the RESERVED level on,
+ * so no other process holds a RESERVED lock while this one holds any lock.
+ */
+static int vedis_extra_file_check_reserved_lock(vedis_file *file, int *reserved)
+{
+    SXUNUSED(file);
+    *reserved = 0;
+    return VEDIS_OK;
+}
+
+static int vedis_extra_file_sector_size(vedis_file *file)
+{
+    SXUNUSED(file);
+    return VEDIS_DEFAULT_SECTOR_SIZE;
+}
+
+static const vedis_io_methods vedis_extra_io_methods = {
+    1,
+    vedis_extra_file_close,
+    vedis_extra_file_read,
+    vedis_extra_file_write,
+    vedis_extra_file_truncate,
+    vedis_extra_file_sync,
+    vedis_extra_file_size,
+    vedis_extra_file_lock,
+    vedis_extra_file_unlock,
+    vedis_extra_file_check_reserved_lock,
+    vedis_extra_file_sector_size
+};
+
+static int vedis_extra_vfs_open(vedis_vfs *vfs, const char *path, vedis_file *file, unsigned int flags)
+{
+    int rc = goVfsOpen(VFS_HANDLE(vfs), (char *)path, flags, &FILE_HANDLE(file));
+    if( rc == VEDIS_OK ){
+        file->pMethods = &vedis_extra_io_methods;
+    }
+    return rc;
+}
+
+static int vedis_extra_vfs_delete(vedis_vfs *vfs, const char *path, int sync_dir)
+{
+    SXUNUSED(sync_dir);
+    return goVfsDelete(VFS_HANDLE(vfs), (char *)path);
+}
+
+static int vedis_extra_vfs_access(vedis_vfs *vfs, const char *path, int flags, int *exists)
+{
+    SXUNUSED(flags);
+    return goVfsAccess(VFS_HANDLE(vfs), (char *)path, exists);
+}
+
+/* Paths are handed to the Go side as they were given */
+static int vedis_extra_vfs_full_pathname(vedis_vfs *vfs, const char *path, int size, char *out)
+{
+    int len = (int)SyStrlen(path);
+    SXUNUSED(vfs);
+    if( len >= size ){
+        return VEDIS_FULL;
+    }
+    SyMemcpy(path, out, (sxu32)len);
+    out[len] = 0;
+    return VEDIS_OK;
+}
+
+vedis_vfs * vedis_extra_vfs_new(uintptr_t handle)
+{
+    vedis_extra_vfs *vfs;
+    vfs = (vedis_extra_vfs *)calloc(1, sizeof(vedis_extra_vfs));
+    if( vfs == 0 ){
+        return 0;
+    }
+    vfs->base = *vedisExportBuiltinVfs();
+    vfs->base.zName = "go";
+    vfs->base.szOsFile = sizeof(vedis_extra_file);
+    vfs->base.xOpen = vedis_extra_vfs_open;
+    vfs->base.xDelete = vedis_extra_vfs_delete;
+    vfs->base.xAccess = vedis_extra_vfs_access;
+    vfs->base.xFullPathname = vedis_extra_vfs_full_pathname;
+    vfs->base.xMmap = 0;
+    vfs->base.xUnmap = 0;
+    vfs->handle = handle;
+    return &vfs->base;
+}
+
+uintptr_t vedis_extra_vfs_handle(vedis_vfs *vfs)
+{
+    return VFS_HANDLE(vfs);
+}
+
+void vedis_extra_vfs_free(vedis_vfs *vfs)
+{
+    free(vfs);
+}
+
+/* Page cache of the datastores opened from now on, 0 for no limit */
+static int vedis_extra_page_cache;
+
+/*
+ * vedis_open() with open flags, a VFS and a page size of its own, the library
+ * wide VFS and page size being swapped only while the pager is set up. The
+ * page size is then kept by the pager until it reads the datastore header.
+ */
+int vedis_extra_open(vedis **store, const char *path, unsigned int flags, vedis_vfs *vfs, int page_size)
+{
+    vedis_vfs *saved;
+    vedis *handle;
+    int saved_size;
+    int rc;
+    *store = 0;
+    pthread_mutex_lock(&vedis_extra_mutex);
+    rc = vedisCoreInitialize();
+    if( rc != VEDIS_OK ){
+        pthread_mutex_unlock(&vedis_extra_mutex);
+        return rc;
+    }
+    handle = (vedis *)SyMemBackendPoolAlloc(&sVedisMPGlobal.sAllocator, sizeof(vedis));
+    if( handle == 0 ){
+        pthread_mutex_unlock(&vedis_extra_mutex);
+        return VEDIS_NOMEM;
+    }
+    SyZero(handle, sizeof(vedis));
+    saved = sVedisMPGlobal.pVfs;
+    saved_size = sVedisMPGlobal.iPageSize;
+    if( vfs ){
+        sVedisMPGlobal.pVfs = vfs;
+    }
+    if( page_size > 0 ){
+        sVedisMPGlobal.iPageSize = page_size;
+    }
+    rc = vedisInitDatabase(handle, &sVedisMPGlobal.sAllocator, path, flags);
+    if( rc == VEDIS_OK ){
+        handle->pPager->iPageSize = vedisGetPageSize();
+        if( vedis_extra_page_cache > 0 ){
+            vedisPagerSetCachesize(handle->pPager, vedis_extra_page_cache);
+        }
+    }
+    sVedisMPGlobal.pVfs = saved;
+    sVedisMPGlobal.iPageSize = saved_size;
+    if( rc != VEDIS_OK ){
+        SyMemBackendRelease(&handle->sMem);
+        SyMemBackendPoolFree(&sVedisMPGlobal.sAllocator, handle);
+        pthread_mutex_unlock(&vedis_extra_mutex);
+        return rc;
+    }
+    handle->nMagic = VEDIS_DB_MAGIC;
+    vedisRegisterBuiltinCommands(handle);
+    vedisPagerSetCommitCallback(handle->pPager, vedisOnCommit, handle);
+    MACRO_LD_PUSH(sVedisMPGlobal.pStore, handle);
+    sVedisMPGlobal.nStore++;
+    pthread_mutex_unlock(&vedis_extra_mutex);
+    *store = handle;
+    return VEDIS_OK;
+}
+
+/* vedis_close() unlinking the datastore from the list of open ones under the mutex */
+int vedis_extra_close(vedis *store)
+{
+    int rc;
+    pthread_mutex_lock(&vedis_extra_mutex);
+    rc = vedis_close(store);
+    pthread_mutex_unlock(&vedis_extra_mutex);
+    return rc;
+}
+
+/*
+ * Memory accounting: every chunk allocated by the engine is prefixed with
+ * its size, so the bytes in use can be tracked and capped.
+ */
+#define VEDIS_EXTRA_CHUNK_HEADER 16
+
+static vedis_int64 vedis_extra_mem_limit;
+static vedis_int64 vedis_extra_mem_used;
+static vedis_int64 vedis_extra_mem_peak;
+static vedis_int64 vedis_extra_mem_chunks;
+static vedis_int64 vedis_extra_mem_failures;
+/* Set when an allocation of the current thread fails, see vedis_extra_memory_failed() */
+static __thread int vedis_extra_mem_failed;
+
+static void vedis_extra_mem_fail(void)
+{
+    __atomic_add_fetch(&vedis_extra_mem_failures, 1, __ATOMIC_RELAXED);
+    vedis_extra_mem_failed = 1;
+}
+
+/* Reserve size bytes, failing when the limit would be crossed */
+static int vedis_extra_mem_reserve(vedis_int64 size)
+{
+    vedis_int64 limit = __atomic_load_n(&vedis_extra_mem_limit, __ATOMIC_RELAXED);
+    vedis_int64 used = __atomic_add_fetch(&vedis_extra_mem_used, size, __ATOMIC_RELAXED);
+    vedis_int64 peak;
+    if( limit > 0 && size > 0 && used > limit ){
+        __atomic_sub_fetch(&vedis_extra_mem_used, size, __ATOMIC_RELAXED);
+        vedis_extra_mem_fail();
+        return 0;
+    }
+    peak = __atomic_load_n(&vedis_extra_mem_peak, __ATOMIC_RELAXED);
+    while( used > peak && !__atomic_compare_exchange_n(&vedis_extra_mem_peak, &peak, used, 1, __ATOMIC_RELAXED, __ATOMIC_RELAXED) ){
+    }
+    return 1;
+}
+
+static void * vedis_extra_mem_alloc(unsigned int size)
+{
+    char *chunk;
+    if( !vedis_extra_mem_reserve(size) ){
+        return 0;
+    }
+    chunk = (char *)malloc(VEDIS_EXTRA_CHUNK_HEADER + size);
+    if( chunk == 0 ){
+        __atomic_sub_fetch(&vedis_extra_mem_used, (vedis_int64)size, __ATOMIC_RELAXED);
+        vedis_extra_mem_fail();
+        return 0;
+    }
+    *(unsigned int *)chunk = size;
+    __atomic_add_fetch(&vedis_extra_mem_chunks, 1, __ATOMIC_RELAXED);
+    return chunk + VEDIS_EXTRA_CHUNK_HEADER;
+}
+
+static void * vedis_extra_mem_realloc(void *ptr, unsigned int size)
+{
+    char *chunk;
+    unsigned int old;
+    if( ptr == 0 ){
+        return vedis_extra_mem_alloc(size);
+    }
+    chunk = (char *)ptr - VEDIS_EXTRA_CHUNK_HEADER;
+    old = *(unsigned int *)chunk;
+    if( !vedis_extra_mem_reserve((vedis_int64)size - (vedis_int64)old) ){
+        return 0;
+    }
+    chunk = (char *)realloc(chunk, VEDIS_EXTRA_CHUNK_HEADER + size);
+    if( chunk == 0 ){
+        __atomic_sub_fetch(&vedis_extra_mem_used, (vedis_int64)size - (vedis_int64)old, __ATOMIC_RELAXED);
+        vedis_extra_mem_fail();
+        return 0;
+    }
+    *(unsigned int *)chunk = size;
+    return chunk + VEDIS_EXTRA_CHUNK_HEADER;
+}
+
+static void vedis_extra_mem_free(void *ptr)
+{
+    char *chunk;
+    if( ptr == 0 ){
+        return;
+    }
+    chunk = (char *)ptr - VEDIS_EXTRA_CHUNK_HEADER;
+    __atomic_sub_fetch(&vedis_extra_mem_used, (vedis_int64)*(unsigned int *)chunk, __ATOMIC_RELAXED);
+    __atomic_sub_fetch(&vedis_extra_mem_chunks, 1, __ATOMIC_RELAXED);
+    free(chunk);
+}
+
+static unsigned int vedis_extra_mem_chunk_size(void *ptr)
+{
+    return *(unsigned int *)((char *)ptr - VEDIS_EXTRA_CHUNK_HEADER);
+}
+
+static const SyMemMethods vedis_extra_mem_methods = {
+    vedis_extra_mem_alloc,
+    vedis_extra_mem_realloc,
+    vedis_extra_mem_free,
+    vedis_extra_mem_chunk_size,
+    0,
+    0,
+    0
+};
+
+static int vedis_extra_mem_error(void *data)
+{
+    SXUNUSED(data);
+    goMemoryError();
+    return SXERR_ABORT;
+}
+
+/* Must run before the library is initialized */
+int vedis_extra_memory_init(void)
+{
+    int rc = vedis_lib_config(VEDIS_LIB_CONFIG_USER_MALLOC, &vedis_extra_mem_methods);
+    if( rc != VEDIS_OK ){
+        return rc;
+    }
+    return vedis_lib_config(VEDIS_LIB_CONFIG_MEM_ERR_CALLBACK, vedis_extra_mem_error, (void *)0);
+}
+
+vedis_int64 vedis_extra_memory_limit(vedis_int64 limit)
+{
+    return __atomic_exchange_n(&vedis_extra_mem_limit, limit, __ATOMIC_RELAXED);
+}
+
+/* Tell whether an allocation of the current thread failed since the last call */
+int vedis_extra_memory_failed(void)
+{
+    int failed = vedis_extra_mem_failed;
+    vedis_extra_mem_failed = 0;
+    return failed;
+}
+
+void vedis_extra_memory_stats(vedis_int64 *used, vedis_int64 *peak, vedis_int64 *chunks, vedis_int64 *failures, vedis_int64 *limit)
+{
+    *limit = __atomic_load_n(&vedis_extra_mem_limit, __ATOMIC_RELAXED);
+    *used = __atomic_load_n(&vedis_extra_mem_used, __ATOMIC_RELAXED);
+    *peak = __atomic_load_n(&vedis_extra_mem_peak, __ATOMIC_RELAXED);
+    *chunks = __atomic_load_n(&vedis_extra_mem_chunks, __ATOMIC_RELAXED);
+    *failures = __atomic_load_n(&vedis_extra_mem_failures, __ATOMIC_RELAXED);
+}
+
+/*
+ * Page size of the datastores created from now on, the library being
+ * locked by vedis_lib_config() once initialized.
+ */
+int vedis_extra_set_page_size(int size)
+{
+    if( size < VEDIS_MIN_PAGE_SIZE || size > VEDIS_MAX_PAGE_SIZE || (size & (size - 1)) != 0 ){
+        return VEDIS_INVALID;
+    }
+    pthread_mutex_lock(&vedis_extra_mutex);
+    sVedisMPGlobal.iPageSize = size;
+    pthread_mutex_unlock(&vedis_extra_mutex);
+    return VEDIS_OK;
+}
+
+int vedis_extra_page_size(vedis *store)
+{
+    int size;
+    if( store ){
+        return store->pPager->iPageSize;
+    }
+    pthread_mutex_lock(&vedis_extra_mutex);
+    size = vedisGetPageSize();
+    pthread_mutex_unlock(&vedis_extra_mutex);
+    return size;
+}
+
+int vedis_extra_max_page_cache(vedis *store, int pages)
+{
+    return vedis_config(store, VEDIS_CONFIG_MAX_PAGE_CACHE, pages);
+}
+
+/* Page cache of the datastores opened from now on, 0 removing the limit */
+int vedis_extra_set_page_cache(int pages)
+{
+    if( pages != 0 && pages < 256 ){
+        return VEDIS_INVALID;
+    }
+    pthread_mutex_lock(&vedis_extra_mutex);
+    vedis_extra_page_cache = pages;
+    pthread_mutex_unlock(&vedis_extra_mutex);
+    return VEDIS_OK;
+}
+
+int vedis_extra_page_cache_size(void)
+{
+    int pages;
+    pthread_mutex_lock(&vedis_extra_mutex);
+    pages = vedis_extra_page_cache;
+    pthread_mutex_unlock(&vedis_extra_mutex);
+    return pages;
+}
+
+int vedis_extra_disable_auto_commit(vedis *store)
+{
+    return vedis_config(store, VEDIS_CONFIG_DISABLE_AUTO_COMMIT);
+}
+
+/*
+ * Validate a raw page of the hash engine, whose parser trusts the offsets
+ * and lengths it reads: cell and free block chains must stay inside the
+ * page without looping, payloads inside the page or the file.
+ */
+static int vedis_extra_check_page(lhash_kv_engine *engine, pgno db_size, const unsigned char *raw, pgno *slave)
+{
+    sxu32 size = engine->iPageSize;
+    sxu64 max = (sxu64)size * db_size;
+    sxu16 offset, next;
+    sxu64 data, ovfl;
+    sxu32 key, n;
+    SyBigEndianUnpack16(raw, &offset);
+    SyBigEndianUnpack16(&raw[2], &next);
+    SyBigEndianUnpack64(&raw[4], slave);
+    if( *slave >= db_size ){
+        return VEDIS_CORRUPT;
+    }
+    /* Free blocks */
+    for( n = 0 ; next > 0 ; ++n ){
+        if( n > size / 4 || next < L_HASH_PAGE_HDR_SZ || (sxu32)next + 4 > size ){
+            return VEDIS_CORRUPT;
+        }
+        SyBigEndianUnpack16(&raw[next], &next);
+    }
+    /* Cells */
+    for( n = 0 ; offset > 0 ; ++n ){
+        if( n > size / L_HASH_CELL_SZ || offset < L_HASH_PAGE_HDR_SZ || (sxu32)offset + L_HASH_CELL_SZ > size ){
+            return VEDIS_CORRUPT;
+        }
+        SyBigEndianUnpack32(&raw[offset + 4], &key);
+        SyBigEndianUnpack64(&raw[offset + 8], &data);
+        SyBigEndianUnpack16(&raw[offset + 16], &next);
+        SyBigEndianUnpack64(&raw[offset + 18], &ovfl);
+        if( ovfl == 0 ){
+            if( data > size || (sxu64)offset + L_HASH_CELL_SZ + key + data > size ){
+                return VEDIS_CORRUPT;
+            }
+        }else if( ovfl >= db_size || data > max || key > max - data ){
+            return VEDIS_CORRUPT;
+        }
+        offset = next;
+    }
+    return VEDIS_OK;
+}
+
+/* Report every record of an engine other than the hash engine */
+static int vedis_extra_check_cursor(vedis *store, uintptr_t handle)
+{
+    vedis_kv_methods *methods = vedisPagerGetKvEngine(store)->pIo->pMethods;
+    vedis_kv_cursor *cursor;
+    SyBlob key, data;
+    int rc, status;
+
+    rc = vedisInitCursor(store, &cursor);
+    if( rc != VEDIS_OK ){
+        return rc;
+    }
+    SyBlobInit(&key, &store->sMem);
+    SyBlobInit(&data, &store->sMem);
+    for( rc = methods->xFirst(cursor) ; rc == VEDIS_OK && methods->xValid(cursor) ; rc = methods->xNext(cursor) ){
+        SyBlobReset(&key);
+        SyBlobReset(&data);
+        status = methods->xKey(cursor, vedisDataConsumer, &key);
+        if( status == VEDIS_OK ){
+            status = methods->xData(cursor, vedisDataConsumer, &data);
+        }
+        goCheckRecord(handle, 0, SyBlobData(&key), (int)SyBlobLength(&key), SyBlobData(&data), (int)SyBlobLength(&data), status);
+    }
+    SyBlobRelease(&key);
+    SyBlobRelease(&data);
+    vedisReleaseCursor(store, cursor);
+    return rc == VEDIS_DONE || rc == VEDIS_EOF || rc == VEDIS_NOTFOUND ? VEDIS_OK : rc;
+}
+
+/*
+ * Walk every bucket of the hash engine, validating its pages before the
+ * engine loads them, then read every cell back and check its key against
+ * its hash. Buckets which can not be loaded are reported and skipped,
+ * records are reported with their status, so the walk goes on past
+ * corruption.
+ */
+static int vedis_extra_check_hash(vedis *store, uintptr_t handle)
+{
+    vedis_kv_engine *engine = vedisPagerGetKvEngine(store);
+    lhash_kv_engine *hash = (lhash_kv_engine *)engine;
+    lhash_bmap_rec *rec;
+    vedis_page *raw;
+    lhpage *page;
+    lhcell *cell;
+    SyBlob key, data;
+    pgno pnum, slave, db_size;
+    int rc, n;
+
+    /* Read the database header and the bucket map */
+    rc = engine->pIo->xGet(engine->pIo->pHandle, 1, 0);
+    if( rc != VEDIS_OK ){
+        return rc;
+    }
+    db_size = store->pPager->dbSize;
+    SyBlobInit(&key, &store->sMem);
+    SyBlobInit(&data, &store->sMem);
+    for( rec = hash->pFirst ; rec ; rec = rec->pPrev ){
+        /* The bucket page and its slaves */
+        rc = VEDIS_OK;
+        pnum = rec->iReal;
+        for( n = 0 ; n < 128 && pnum > 0 ; ++n ){
+            if( pnum >= db_size ){
+                rc = VEDIS_CORRUPT;
+                break;
+            }
+            rc = engine->pIo->xGet(engine->pIo->pHandle, pnum, &raw);
+            if( rc != VEDIS_OK ){
+                break;
+            }
+            if( raw->pUserData ){
+                slave = ((lhpage *)raw->pUserData)->sHdr.iSlave;
+            }else{
+                rc = vedis_extra_check_page(hash, db_size, raw->zData, &slave);
+            }
+            engine->pIo->xPageUnref(raw);
+            if( rc != VEDIS_OK ){
+                break;
+            }
+            pnum = slave;
+        }
+        if( rc == VEDIS_OK ){
+            pnum = rec->iReal;
+            rc = lhLoadPage(hash, pnum, 0, &page, 0);
+        }
+        goCheckPage(handle, (vedis_int64)pnum, rc);
+        if( rc != VEDIS_OK ){
+            continue;
+        }
+        for( cell = page->pList ; cell ; cell = cell->pNext ){
+            SyBlobReset(&key);
+            SyBlobReset(&data);
+            rc = lhConsumeCellkey(cell, vedisDataConsumer, &key, 0);
+            if( rc == VEDIS_OK && (SyBlobLength(&key) != cell->nKey || hash->xHash(SyBlobData(&key), cell->nKey) != cell->nHash) ){
+                rc = VEDIS_CORRUPT;
+            }
+            if( rc == VEDIS_OK && cell->iOvfl > 0 && cell->iDataOfft >= hash->iPageSize ){
+                rc = VEDIS_CORRUPT;
+            }
+            if( rc == VEDIS_OK ){
+                rc = lhConsumeCellData(cell, vedisDataConsumer, &data);
+            }
+            if( rc == VEDIS_OK && SyBlobLength(&data) != cell->nData ){
+                rc = VEDIS_CORRUPT;
+            }
+            goCheckRecord(handle, (vedis_int64)cell->pPage->pRaw->pgno, SyBlobData(&key), (int)SyBlobLength(&key),
+                SyBlobData(&data), (int)SyBlobLength(&data), rc);
+        }
+        engine->pIo->xPageUnref(page->pRaw);
+    }
+    SyBlobRelease(&key);
+    SyBlobRelease(&data);
+    return VEDIS_OK;
+}
+
+/*
+ * Walk every record of the datastore, with vedis_extra_check_hash() on the
+ * hash engine and a cursor on other engines, then report the tables loaded
+ * in memory, whose header records are only written on commit, with the
+ * number of entries a commit would write. Nothing is written.
+ */
+int vedis_extra_check(vedis *store, uintptr_t handle)
+{
+    vedis_table *table;
+    sxu32 n;
+    int rc;
+
+    if( vedisPagerGetKvEngine(store)->pIo->pMethods->xInit == lhash_kv_init ){
+        rc = vedis_extra_check_hash(store, handle);
+    }else{
+        rc = vedis_extra_check_cursor(store, handle);
+    }
+    if( rc != VEDIS_OK ){
+        return rc;
+    }
+    table = store->pTableList;
+    for( n = 0 ; n < store->nTable ; ++n ){
+        goCheckTable(handle, table->iTableType, (void *)table->sName.zString, (int)table->sName.nByte, table->nEntry);
+        table = table->pNext;
+    }
+    return VEDIS_OK;
+}
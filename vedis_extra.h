@@ -1,8 +1,58 @@
 #ifndef _VEDIS_EXTRA_H_
 #define _VEDIS_EXTRA_H_
 
+#include <stdint.h>
 #include "vedis.h"
 
+/* Kind of values held by a key, tables use the engine table types */
+#define VEDIS_EXTRA_STRING 0
+#define VEDIS_EXTRA_HASH   1
+#define VEDIS_EXTRA_SET    2
+#define VEDIS_EXTRA_LIST   3
+
+/* Cursor moves of the storage engines implemented in Go */
+#define VEDIS_EXTRA_FIRST  0
+#define VEDIS_EXTRA_LAST   1
+#define VEDIS_EXTRA_NEXT   2
+#define VEDIS_EXTRA_PREV   3
+
+/* Open flags handed to the virtual file systems implemented in Go */
+#define VEDIS_EXTRA_OPEN_READONLY  0x01
+#define VEDIS_EXTRA_OPEN_READWRITE 0x02
+#define VEDIS_EXTRA_OPEN_CREATE    0x04
+#define VEDIS_EXTRA_OPEN_EXCLUSIVE 0x08
+
 void vedis_error_message(vedis *store, const char **message);
+void vedis_extra_kv_name(vedis *store, const char **name);
+
+int vedis_extra_keys(vedis *store, uintptr_t handle);
+int vedis_extra_entries(vedis *store, int type, const void *name, int name_len, uintptr_t handle);
+int vedis_extra_insert(vedis *store, int type, const void *name, int name_len, const void *key, int key_len, const void *data, int data_len);
+int vedis_extra_clear(vedis *store, int type, const void *name, int name_len);
+int vedis_extra_fetch(vedis *store, int type, const void *name, int name_len, const void *key, int key_len, uintptr_t handle);
+int vedis_extra_remove(vedis *store, int type, const void *name, int name_len, const void *key, int key_len);
+int vedis_extra_pop(vedis *store, int type, const void *name, int name_len, uintptr_t handle);
+int vedis_extra_count(vedis *store, int type, const void *name, int name_len);
+int vedis_extra_register(vedis *store);
+int vedis_extra_call(vedis *store, const char *name, int argc, const char **args, const int *lens);
+int vedis_extra_register_engine(const char *name, uintptr_t factory);
+int vedis_extra_kv_engine(vedis *store, const char *name);
+int vedis_extra_flush_tables(vedis *store);
+vedis_vfs * vedis_extra_vfs_new(uintptr_t handle);
+uintptr_t vedis_extra_vfs_handle(vedis_vfs *vfs);
+void vedis_extra_vfs_free(vedis_vfs *vfs);
+int vedis_extra_open(vedis **store, const char *path, unsigned int flags, vedis_vfs *vfs, int page_size);
+int vedis_extra_close(vedis *store);
+int vedis_extra_memory_init(void);
+vedis_int64 vedis_extra_memory_limit(vedis_int64 limit);
+int vedis_extra_memory_failed(void);
+void vedis_extra_memory_stats(vedis_int64 *used, vedis_int64 *peak, vedis_int64 *chunks, vedis_int64 *failures, vedis_int64 *limit);
+int vedis_extra_set_page_size(int size);
+int vedis_extra_page_size(vedis *store);
+int vedis_extra_max_page_cache(vedis *store, int pages);
+int vedis_extra_set_page_cache(int pages);
+int vedis_extra_page_cache_size(void);
+int vedis_extra_disable_auto_commit(vedis *store);
+int vedis_extra_check(vedis *store, uintptr_t handle);
 
 #endif /* _VEDIS_EXTRA_H_ */
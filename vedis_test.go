@@ -1,7 +1,11 @@
 package vedis
 
 import (
+	"errors"
 	"github.com/stretchr/testify/suite"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -155,6 +159,87 @@ func (suite *VedisTestSuite) TestMove() {
 	}
 }
 
+func (suite *VedisTestSuite) TestType() {
+	suite.store.Set("name", "John")
+	suite.store.HSet("config", "url", "github.com")
+	NewSet(suite.store, "colors", StringCodec{}).Add("red")
+	NewList(suite.store, "queue", StringCodec{}).Push("job")
+
+	for key, expected := range map[string]string{"name": TypeString, "config": TypeHash, "colors": TypeSet, "queue": TypeList, "missing": TypeNone} {
+		if typ, err := suite.store.Type(key); err != nil {
+			suite.Fail(err.Error())
+		} else {
+			suite.Equal(expected, typ, key)
+		}
+	}
+
+	if size, err := suite.store.DBSize(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(4, size)
+	}
+
+	if key, err := suite.store.RandomKey(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Contains([]string{"name", "config", "colors", "queue"}, key)
+	}
+}
+
+func (suite *VedisTestSuite) TestRename() {
+	suite.store.Set("name", "John")
+	suite.store.HSet("config", "url", "github.com")
+
+	if ok, err := suite.store.Rename("config", "settings"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+
+	if value, err := suite.store.HGet("settings", "url"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("github.com", value)
+	}
+
+	if typ, err := suite.store.Type("config"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(TypeNone, typ)
+	}
+
+	// the new name is taken, even by another type
+	if ok, err := suite.store.Rename("name", "settings"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.False(ok)
+	}
+
+	if ok, err := suite.store.Rename("missing", "other"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.False(ok)
+	}
+
+	if ok, err := suite.store.Rename("name", "nickname"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+
+	if value, err := suite.store.Get("nickname"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("John", value)
+	}
+
+	if exists, err := suite.store.Exists("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.False(exists)
+	}
+}
+
 func (suite *VedisTestSuite) TestMassiveSetAndMassiveGet() {
 	if ok, err := suite.store.MSet("name", "John", "age", "29"); err != nil {
 		suite.Fail(err.Error())
@@ -245,7 +330,7 @@ func (suite *VedisTestSuite) TestIncr() {
 	if value, err := suite.store.Incr("count"); err != nil {
 		suite.Fail(err.Error())
 	} else {
-		suite.Equal(13, value)
+		suite.Equal(int64(13), value)
 	}
 }
 
@@ -259,7 +344,7 @@ func (suite *VedisTestSuite) TestIncrBy() {
 	if value, err := suite.store.IncrBy("count", 10); err != nil {
 		suite.Fail(err.Error())
 	} else {
-		suite.Equal(22, value)
+		suite.Equal(int64(22), value)
 	}
 }
 
@@ -273,7 +358,7 @@ func (suite *VedisTestSuite) TestDecr() {
 	if value, err := suite.store.Decr("count"); err != nil {
 		suite.Fail(err.Error())
 	} else {
-		suite.Equal(14, value)
+		suite.Equal(int64(14), value)
 	}
 }
 
@@ -287,8 +372,114 @@ func (suite *VedisTestSuite) TestDecrBy() {
 	if value, err := suite.store.DecrBy("count", 3); err != nil {
 		suite.Fail(err.Error())
 	} else {
-		suite.Equal(20, value)
+		suite.Equal(int64(20), value)
+	}
+}
+
+func (suite *VedisTestSuite) TestHSetNX() {
+	if ok, err := suite.store.HSetNX("config", "url", "github.com"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+
+	if ok, err := suite.store.HSetNX("config", "url", "gitlab.com"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.False(ok)
+	}
+
+	if value, err := suite.store.HGet("config", "url"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("github.com", value)
+	}
+}
+
+func (suite *VedisTestSuite) TestHStrLen() {
+	suite.store.HSet("config", "url", "github.com")
+
+	if length, err := suite.store.HStrLen("config", "url"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(10, length)
+	}
+
+	if length, err := suite.store.HStrLen("config", "missing"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(0, length)
+	}
+}
+
+func (suite *VedisTestSuite) TestIncrBy64Bit() {
+	if value, err := suite.store.IncrBy("bytes", 5000000000); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(int64(5000000000), value)
+	}
+
+	if value, err := suite.store.Incr("bytes"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(int64(5000000001), value)
 	}
+
+	suite.store.Set("bytes", "9223372036854775807")
+	_, err := suite.store.Incr("bytes")
+	suite.Error(err)
+
+	suite.store.Set("name", "John")
+	_, err = suite.store.IncrBy("name", 1)
+	suite.Error(err)
+}
+
+func (suite *VedisTestSuite) TestIncrByFloat() {
+	suite.store.Set("ratio", "10.5")
+	if value, err := suite.store.IncrByFloat("ratio", 0.1); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(10.6, value)
+	}
+
+	if value, err := suite.store.Get("ratio"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("10.6", value)
+	}
+
+	suite.store.Set("name", "John")
+	_, err := suite.store.IncrByFloat("name", 1)
+	suite.Error(err)
+}
+
+func (suite *VedisTestSuite) TestHIncrBy() {
+	if value, err := suite.store.HIncrBy("user:1", "bytes", 3000000000); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(int64(3000000000), value)
+	}
+
+	if value, err := suite.store.HIncrBy("user:1", "bytes", -1); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(int64(2999999999), value)
+	}
+
+	if value, err := suite.store.HIncrByFloat("user:1", "score", 2.5); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(2.5, value)
+	}
+
+	if hash, err := suite.store.HGetAll("user:1"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]string{"bytes", "2999999999", "score", "2.5"}, hash)
+	}
+
+	_, err := suite.store.HIncrBy("user:1", "score", 1)
+	suite.Error(err)
 }
 
 func (suite *VedisTestSuite) TestHSetHGet() {
@@ -421,6 +612,139 @@ func (suite *VedisTestSuite) TestHGetAll() {
 	}
 }
 
+func (suite *VedisTestSuite) TestExec() {
+	if value, err := suite.store.Exec("SET name John"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(true, value)
+	}
+
+	if value, err := suite.store.Exec("GET name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("John", value)
+	}
+
+	if value, err := suite.store.Exec("INCRBY count 5"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(int64(5), value)
+	}
+
+	if value, err := suite.store.Exec("MGET name nothing"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal([]interface{}{"John", nil}, value)
+	}
+
+	if _, err := suite.store.Exec("NOTHING"); err == nil {
+		suite.Fail("unknown command executed")
+	}
+}
+
+func (suite *VedisTestSuite) TestOpenFile() {
+	dir, err := os.MkdirTemp("", "vedis")
+	if err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.db")
+
+	store := New()
+	if ok, err := store.OpenFile(path); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+	store.Set("name", "John")
+	store.Close()
+
+	store = New()
+	if ok, err := store.OpenFile(path); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+	defer store.Close()
+
+	if value, err := store.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("John", value)
+	}
+}
+
+func (suite *VedisTestSuite) TestRenameOnDisk() {
+	path := filepath.Join(suite.T().TempDir(), "test.db")
+
+	store := New()
+	store.OpenFile(path)
+	store.HSet("config", "url", "github.com")
+	store.Close()
+
+	store = New()
+	store.OpenFile(path)
+	defer store.Close()
+
+	if ok, err := store.Rename("config", "settings"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+
+	// the header record of the renamed hash is only rewritten on commit
+	if size, err := store.DBSize(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(1, size)
+	}
+}
+
+// A compressor failing on every value it is given.
+type faultyCompressor struct{}
+
+func (faultyCompressor) ID() byte { return 0x7F }
+
+func (faultyCompressor) Compress(data []byte) ([]byte, error) {
+	return nil, errors.New("injected fault")
+}
+
+func (faultyCompressor) Decompress(data []byte) ([]byte, error) {
+	return nil, errors.New("injected fault")
+}
+
+func (suite *VedisTestSuite) TestRenameFault() {
+	store := New()
+	store.OpenFile(filepath.Join(suite.T().TempDir(), "test.db"))
+	defer store.Close()
+	store.Set("config", "short")
+	store.HSet("config", "url", strings.Repeat("github.com", 10))
+
+	// the string is moved, then storing the hash fails
+	store.Compress(faultyCompressor{}, 64)
+	if _, err := store.Rename("config", "settings"); err == nil {
+		suite.Fail("expected the rename to fail")
+	}
+	store.Compress(nil, 0)
+
+	if value, err := store.Get("config"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("short", value)
+	}
+	if types, err := store.types("settings"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Empty(types)
+	}
+	if value, err := store.HGet("config", "url"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal(strings.Repeat("github.com", 10), value)
+	}
+}
+
 func TestVedisTestSuite(t *testing.T) {
 	suite.Run(t, new(VedisTestSuite))
 }
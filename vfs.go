@@ -0,0 +1,435 @@
+package vedis
+
+// #include <stdlib.h>
+// #include "vedis_extra.h"
+import "C"
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"runtime/cgo"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// VFS is a file system written in Go, the counterpart of vedis_vfs.
+// Vedis opens the datastore file and its journal, the path of the datastore suffixed with "_vedis_journal", through it.
+// The datastore file is only locked against other processes when it implements LockFile,
+// and sleeping, time and the temporary directory still come from the operating system.
+//
+// See http://vedis.symisc.net/c_api/vedis_lib_config.html
+type VFS interface {
+	// OpenFile opens the named file with os.O_RDONLY or os.O_RDWR, possibly combined with os.O_CREATE and os.O_EXCL.
+	OpenFile(name string, flag int) (File, error)
+	// Remove removes the named file.
+	Remove(name string) error
+	// Stat describes the named file, failing with an error matching fs.ErrNotExist if it is missing.
+	Stat(name string) (fs.FileInfo, error)
+}
+
+// File is a file opened by a VFS, *os.File being one.
+type File interface {
+	io.ReaderAt
+	io.WriterAt
+	io.Closer
+	Truncate(size int64) error
+	Sync() error
+	Stat() (fs.FileInfo, error)
+}
+
+// LockLevel is a lock held on a datastore file.
+// Vedis raises it to LockShared to read and on to LockExclusive to write, then lowers it back.
+type LockLevel int
+
+const (
+	LockNone      LockLevel = C.VEDIS_LOCK_NONE
+	LockShared    LockLevel = C.VEDIS_LOCK_SHARED
+	LockReserved  LockLevel = C.VEDIS_LOCK_RESERVED
+	LockPending   LockLevel = C.VEDIS_LOCK_PENDING
+	LockExclusive LockLevel = C.VEDIS_LOCK_EXCLUSIVE
+)
+
+// LockFile is a File which can be locked against other processes.
+// Files which do not implement it are never locked, so their datastore must not be shared by several processes.
+type LockFile interface {
+	File
+	// Lock raises the lock held on the file to level, failing with ErrLocked when another process holds a conflicting lock.
+	Lock(level LockLevel) error
+	// Unlock lowers the lock held on the file to level, LockShared or LockNone.
+	Unlock(level LockLevel) error
+}
+
+// DirVFS is a VFS keeping its files under a directory, names can not escape it.
+// Its files are locked with flock on Unix-like systems, shared to read and exclusive from LockReserved on.
+// Vedis keeps the shared lock from the first read until the datastore is closed, so while a datastore is open
+// and was read through another handle, in this process or another one, writes fail rather than corrupt it.
+// Other systems do not lock the files.
+type DirVFS string
+
+func (d DirVFS) OpenFile(name string, flag int) (File, error) {
+	root, err := os.OpenRoot(string(d))
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	f, err := root.OpenFile(name, flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &dirFile{File: f}, nil
+}
+
+func (d DirVFS) Remove(name string) error {
+	root, err := os.OpenRoot(string(d))
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	return root.Remove(name)
+}
+
+func (d DirVFS) Stat(name string) (fs.FileInfo, error) {
+	root, err := os.OpenRoot(string(d))
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	return root.Stat(name)
+}
+
+type dirFile struct {
+	*os.File
+	level LockLevel
+}
+
+func (f *dirFile) Lock(level LockLevel) error {
+	if level <= f.level {
+		return nil
+	}
+	if err := lockFile(f.File, f.level, level); err != nil {
+		return err
+	}
+	f.level = level
+	return nil
+}
+
+func (f *dirFile) Unlock(level LockLevel) error {
+	if level >= f.level {
+		return nil
+	}
+	if err := lockFile(f.File, f.level, level); err != nil {
+		return err
+	}
+	f.level = level
+	return nil
+}
+
+// MemVFS is a VFS keeping its files in memory, for as long as it is referenced.
+// It can be shared by several datastores.
+type MemVFS struct {
+	files map[string]*memData
+	lock  sync.Mutex
+}
+
+// Get a new empty in-memory file system.
+func NewMemVFS() *MemVFS {
+	return &MemVFS{files: make(map[string]*memData)}
+}
+
+type memData struct {
+	data    []byte
+	modTime time.Time
+	lock    sync.RWMutex
+}
+
+func (m *MemVFS) OpenFile(name string, flag int) (File, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	data, ok := m.files[name]
+	if ok && flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	} else if !ok {
+		if flag&os.O_CREATE == 0 {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+		data = &memData{modTime: time.Now()}
+		m.files[name] = data
+	}
+	return &memFile{name: name, data: data, readOnly: flag&(os.O_WRONLY|os.O_RDWR) == 0}, nil
+}
+
+func (m *MemVFS) Remove(name string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+func (m *MemVFS) Stat(name string) (fs.FileInfo, error) {
+	m.lock.Lock()
+	data, ok := m.files[name]
+	m.lock.Unlock()
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return data.stat(name), nil
+}
+
+func (d *memData) stat(name string) fs.FileInfo {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return memInfo{name: name, size: int64(len(d.data)), modTime: d.modTime}
+}
+
+type memFile struct {
+	name     string
+	data     *memData
+	readOnly bool
+	closed   bool
+}
+
+func (f *memFile) check(op string, write bool) error {
+	if f.closed {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	} else if write && f.readOnly {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrPermission}
+	}
+	return nil
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if err := f.check("read", false); err != nil {
+		return 0, err
+	}
+	f.data.lock.RLock()
+	defer f.data.lock.RUnlock()
+	if off >= int64(len(f.data.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) WriteAt(p []byte, off int64) (int, error) {
+	if err := f.check("write", true); err != nil {
+		return 0, err
+	}
+	f.data.lock.Lock()
+	defer f.data.lock.Unlock()
+	if end := off + int64(len(p)); end > int64(len(f.data.data)) {
+		f.data.data = append(f.data.data, make([]byte, end-int64(len(f.data.data)))...)
+	}
+	f.data.modTime = time.Now()
+	return copy(f.data.data[off:], p), nil
+}
+
+func (f *memFile) Truncate(size int64) error {
+	if err := f.check("truncate", true); err != nil {
+		return err
+	}
+	f.data.lock.Lock()
+	defer f.data.lock.Unlock()
+	if size < int64(len(f.data.data)) {
+		f.data.data = bytes.Clone(f.data.data[:size])
+	} else {
+		f.data.data = append(f.data.data, make([]byte, size-int64(len(f.data.data)))...)
+	}
+	f.data.modTime = time.Now()
+	return nil
+}
+
+func (f *memFile) Sync() error {
+	return f.check("sync", false)
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	if err := f.check("stat", false); err != nil {
+		return nil, err
+	}
+	return f.data.stat(f.name), nil
+}
+
+func (f *memFile) Close() error {
+	if err := f.check("close", false); err != nil {
+		return err
+	}
+	f.closed = true
+	return nil
+}
+
+type memInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) Mode() fs.FileMode  { return 0644 }
+func (i memInfo) ModTime() time.Time { return i.modTime }
+func (i memInfo) IsDir() bool        { return false }
+func (i memInfo) Sys() any           { return nil }
+
+// Open the datastore stored at path in vfs.
+// Every file of the datastore goes through vfs, the VFS of the library staying in use for other datastores.
+//
+// See http://vedis.symisc.net/c_api/vedis_open.html
+func (v *Vedis) OpenVFS(path string, vfs VFS) (bool, error) {
+	return v.openVFS(path, vfs, 0)
+}
+
+func (v *Vedis) openVFS(path string, vfs VFS, flags C.uint) (bool, error) {
+	if v.key != nil {
+		encrypted, err := EncryptVFS(vfs, v.key)
+		if err != nil {
+			return false, err
+		}
+		if err := checkKey(encrypted, path); err != nil {
+			return false, err
+		}
+		vfs = encrypted
+	}
+	handle := cgo.NewHandle(vfs)
+	v.vfs = C.vedis_extra_vfs_new(C.uintptr_t(handle))
+	cpath := C.CString(path)
+	defer C.free(unsafe.Pointer(cpath))
+	if status := C.vedis_extra_open(&v.ptr, cpath, flags, v.vfs, C.int(v.pageSize)); status != C.VEDIS_OK {
+		v.releaseVFS()
+		return false, newError(status, v.ptr)
+	}
+	if status := C.vedis_extra_register(v.ptr); status != C.VEDIS_OK {
+		err := newError(status, v.ptr)
+		C.vedis_extra_close(v.ptr)
+		v.ptr = nil
+		v.releaseVFS()
+		return false, err
+	}
+	return true, nil
+}
+
+func (v *Vedis) releaseVFS() {
+	if v.vfs != nil {
+		cgo.Handle(C.vedis_extra_vfs_handle(v.vfs)).Delete()
+		C.vedis_extra_vfs_free(v.vfs)
+		v.vfs = nil
+	}
+}
+
+func fileStatus(err error) C.int {
+	if err != nil {
+		return C.VEDIS_IOERR
+	}
+	return C.VEDIS_OK
+}
+
+//export goVfsOpen
+func goVfsOpen(handle C.uintptr_t, path *C.char, flags C.uint, file *C.uintptr_t) C.int {
+	flag := os.O_RDWR
+	if flags&C.VEDIS_EXTRA_OPEN_READONLY != 0 {
+		flag = os.O_RDONLY
+	}
+	if flags&C.VEDIS_EXTRA_OPEN_CREATE != 0 {
+		flag |= os.O_CREATE
+	}
+	if flags&C.VEDIS_EXTRA_OPEN_EXCLUSIVE != 0 {
+		flag |= os.O_EXCL
+	}
+	f, err := cgo.Handle(handle).Value().(VFS).OpenFile(C.GoString(path), flag)
+	if err != nil {
+		return C.VEDIS_IOERR
+	}
+	*file = C.uintptr_t(cgo.NewHandle(f))
+	return C.VEDIS_OK
+}
+
+//export goVfsDelete
+func goVfsDelete(handle C.uintptr_t, path *C.char) C.int {
+	return fileStatus(cgo.Handle(handle).Value().(VFS).Remove(C.GoString(path)))
+}
+
+//export goVfsAccess
+func goVfsAccess(handle C.uintptr_t, path *C.char, exists *C.int) C.int {
+	_, err := cgo.Handle(handle).Value().(VFS).Stat(C.GoString(path))
+	*exists = 0
+	if errors.Is(err, fs.ErrNotExist) {
+		return C.VEDIS_OK
+	} else if err != nil {
+		return C.VEDIS_IOERR
+	}
+	*exists = 1
+	return C.VEDIS_OK
+}
+
+//export goFileClose
+func goFileClose(handle C.uintptr_t) C.int {
+	defer cgo.Handle(handle).Delete()
+	return fileStatus(cgo.Handle(handle).Value().(File).Close())
+}
+
+// Unread parts of the buffer are zero-filled, a short read being an error.
+//
+//export goFileRead
+func goFileRead(handle C.uintptr_t, buffer unsafe.Pointer, amount, offset C.vedis_int64) C.int {
+	p := unsafe.Slice((*byte)(buffer), int(amount))
+	n, err := cgo.Handle(handle).Value().(File).ReadAt(p, int64(offset))
+	if n < len(p) {
+		clear(p[n:])
+		return C.VEDIS_IOERR
+	} else if err != nil && err != io.EOF {
+		return C.VEDIS_IOERR
+	}
+	return C.VEDIS_OK
+}
+
+//export goFileWrite
+func goFileWrite(handle C.uintptr_t, buffer unsafe.Pointer, amount, offset C.vedis_int64) C.int {
+	_, err := cgo.Handle(handle).Value().(File).WriteAt(unsafe.Slice((*byte)(buffer), int(amount)), int64(offset))
+	return fileStatus(err)
+}
+
+//export goFileTruncate
+func goFileTruncate(handle C.uintptr_t, size C.vedis_int64) C.int {
+	return fileStatus(cgo.Handle(handle).Value().(File).Truncate(int64(size)))
+}
+
+//export goFileSync
+func goFileSync(handle C.uintptr_t) C.int {
+	return fileStatus(cgo.Handle(handle).Value().(File).Sync())
+}
+
+//export goFileLock
+func goFileLock(handle C.uintptr_t, level C.int, raise C.int) C.int {
+	f, ok := cgo.Handle(handle).Value().(File).(LockFile)
+	if !ok {
+		return C.VEDIS_OK
+	}
+	var err error
+	if raise != 0 {
+		err = f.Lock(LockLevel(level))
+	} else {
+		err = f.Unlock(LockLevel(level))
+	}
+	if errors.Is(err, ErrLocked) {
+		return C.VEDIS_BUSY
+	}
+	return fileStatus(err)
+}
+
+//export goFileSize
+func goFileSize(handle C.uintptr_t, size *C.vedis_int64) C.int {
+	info, err := cgo.Handle(handle).Value().(File).Stat()
+	if err != nil {
+		return C.VEDIS_IOERR
+	}
+	*size = C.vedis_int64(info.Size())
+	return C.VEDIS_OK
+}
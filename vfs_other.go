@@ -0,0 +1,10 @@
+//go:build !unix
+
+package vedis
+
+import "os"
+
+// Files are not locked on this system.
+func lockFile(f *os.File, from, to LockLevel) error {
+	return nil
+}
@@ -0,0 +1,149 @@
+package vedis
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// A VFS failing every write once armed, counting the files left open.
+type faultyVFS struct {
+	VFS
+	fail bool
+	open int
+}
+
+type faultyFile struct {
+	File
+	vfs *faultyVFS
+}
+
+func (v *faultyVFS) OpenFile(name string, flag int) (File, error) {
+	f, err := v.VFS.OpenFile(name, flag)
+	if err != nil {
+		return nil, err
+	}
+	v.open++
+	return &faultyFile{f, v}, nil
+}
+
+func (f *faultyFile) Close() error {
+	f.vfs.open--
+	return f.File.Close()
+}
+
+func (f *faultyFile) WriteAt(p []byte, off int64) (int, error) {
+	if f.vfs.fail {
+		return 0, errors.New("injected fault")
+	}
+	return f.File.WriteAt(p, off)
+}
+
+func (suite *VedisTestSuite) TestMemVFS() {
+	vfs := NewMemVFS()
+	store := New()
+	if _, err := store.OpenVFS("test.db", vfs); err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	store.Set("name", "John")
+	store.HSet("config", "url", "github.com")
+	store.Close()
+
+	if info, err := vfs.Stat("test.db"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Positive(info.Size())
+	}
+	_, err := vfs.Stat("test.db_vedis_journal")
+	suite.ErrorIs(err, fs.ErrNotExist)
+
+	store = New()
+	store.OpenVFS("test.db", vfs)
+	defer store.Close()
+
+	if value, err := store.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("John", value)
+	}
+
+	if value, err := store.HGet("config", "url"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("github.com", value)
+	}
+}
+
+func (suite *VedisTestSuite) TestDirVFS() {
+	dir := suite.T().TempDir()
+	store := New()
+	if _, err := store.OpenVFS("test.db", DirVFS(dir)); err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	store.Set("name", "John")
+	store.Close()
+	suite.FileExists(filepath.Join(dir, "test.db"))
+
+	_, err := DirVFS(dir).OpenFile("../escape.db", os.O_RDWR|os.O_CREATE)
+	suite.Error(err)
+	suite.NoFileExists(filepath.Join(filepath.Dir(dir), "escape.db"))
+
+	f, err := DirVFS(dir).OpenFile("test.db", os.O_RDONLY)
+	if err != nil {
+		suite.Fail(err.Error())
+		return
+	}
+	other, _ := DirVFS(dir).OpenFile("test.db", os.O_RDONLY)
+	suite.NoError(f.(LockFile).Lock(LockShared))
+	suite.NoError(other.(LockFile).Lock(LockShared))
+	suite.ErrorIs(other.(LockFile).Lock(LockExclusive), ErrLocked)
+	suite.NoError(f.(LockFile).Unlock(LockNone))
+	suite.NoError(other.(LockFile).Lock(LockExclusive))
+	f.Close()
+	other.Close()
+
+	// a reader locks writers out until it is closed
+	writer, reader := New(), New()
+	writer.OpenVFS("test.db", DirVFS(dir))
+	defer writer.Close()
+	reader.OpenVFS("test.db", DirVFS(dir))
+	reader.Get("name")
+	if ok, err := writer.Set("name", "Jane"); err == nil {
+		suite.False(ok)
+	}
+	reader.Close()
+	if ok, err := writer.Set("name", "Jane"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+}
+
+func (suite *VedisTestSuite) TestVFSFault() {
+	vfs := &faultyVFS{VFS: NewMemVFS()}
+	store := New()
+	store.OpenVFS("test.db", vfs)
+	defer store.Close()
+	store.Set("name", "John")
+	if _, err := store.Commit(); err != nil {
+		suite.Fail(err.Error())
+	}
+
+	vfs.fail = true
+	if ok, err := store.Set("name", "Jane"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.False(ok)
+	}
+	vfs.fail = false
+	store.Rollback()
+
+	if value, err := store.Get("name"); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.Equal("John", value)
+	}
+}
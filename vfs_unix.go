@@ -0,0 +1,29 @@
+//go:build unix
+
+package vedis
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// Moves the flock of f from one level to another, keeping the shared lock when an upgrade fails.
+func lockFile(f *os.File, from, to LockLevel) error {
+	how := syscall.LOCK_UN
+	switch {
+	case to >= LockReserved:
+		how = syscall.LOCK_EX
+	case to == LockShared:
+		how = syscall.LOCK_SH
+	}
+	err := syscall.Flock(int(f.Fd()), how|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		// converting a flock is not atomic, the shared lock may be gone
+		if from == LockShared {
+			syscall.Flock(int(f.Fd()), syscall.LOCK_SH|syscall.LOCK_NB)
+		}
+		return ErrLocked
+	}
+	return err
+}
@@ -0,0 +1,162 @@
+package vedis
+
+import (
+	"strings"
+	"sync"
+)
+
+// Event describes a change made to a key through this binding.
+type Event struct {
+	// Key that was changed.
+	Key string
+	// Field of the hash that was changed, empty for non hash commands.
+	Field string
+	// Op is the Vedis command that changed the key (i.e. SET, DEL, HSET).
+	Op string
+	// Old value of the key (or hash field), when known.
+	Old string
+	// New value of the key (or hash field), empty when the key was removed.
+	New string
+}
+
+type watcher struct {
+	prefix string
+	fn     func(Event)
+}
+
+type watchers struct {
+	sync.Mutex
+	list     []*watcher
+	inTx     bool
+	pending  []Event
+	versions map[string]*version
+	// keys written inside the transaction, their versions are bumped on commit
+	touched []string
+}
+
+// Watch calls fn after every successful change to a key starting with prefix,
+// including the keys loaded by Restore, ImportJSON and ImportRDB, but not the changes made by Exec.
+// Inside a transaction the events are held until Commit and dropped on Rollback.
+// The returned function removes the watcher.
+func (v *Vedis) Watch(prefix string, fn func(Event)) func() {
+	w := &watcher{prefix, fn}
+	v.watchers.Lock()
+	v.watchers.list = append(v.watchers.list, w)
+	v.watchers.Unlock()
+	return func() {
+		v.watchers.Lock()
+		defer v.watchers.Unlock()
+		for i, other := range v.watchers.list {
+			if other == w {
+				list := make([]*watcher, 0, len(v.watchers.list)-1)
+				v.watchers.list = append(append(list, v.watchers.list[:i]...), v.watchers.list[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (v *Vedis) watched(key string) bool {
+	v.watchers.Lock()
+	defer v.watchers.Unlock()
+	if _, ok := v.watchers.versions[key]; ok {
+		return true
+	}
+	for _, w := range v.watchers.list {
+		if strings.HasPrefix(key, w.prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the current value of key, only when someone is watching it.
+func (v *Vedis) peek(key string) string {
+	if !v.watched(key) {
+		return ""
+	}
+	value, _ := v.Get(key)
+	return value
+}
+
+// Returns the current value of a hash field, only when someone is watching the hash.
+func (v *Vedis) hpeek(key string, field string) string {
+	if !v.watched(key) {
+		return ""
+	}
+	value, _ := v.HGet(key, field)
+	return value
+}
+
+func (v *Vedis) notify(events ...Event) {
+	v.watchers.Lock()
+	if v.watchers.inTx {
+		v.watchers.pending = append(v.watchers.pending, events...)
+		v.watchers.Unlock()
+		return
+	}
+	list := v.watchers.list
+	v.watchers.Unlock()
+	dispatch(list, events)
+}
+
+// Records a write to keys, which changes their version, on commit inside a transaction.
+func (v *Vedis) touch(keys []string) {
+	v.watchers.Lock()
+	defer v.watchers.Unlock()
+	if len(v.watchers.versions) == 0 {
+		return
+	}
+	if v.watchers.inTx {
+		v.watchers.touched = append(v.watchers.touched, keys...)
+		return
+	}
+	v.watchers.bump(keys)
+}
+
+// Reports whether a transaction was started by Begin.
+func (v *Vedis) inTx() bool {
+	v.watchers.Lock()
+	defer v.watchers.Unlock()
+	return v.watchers.inTx
+}
+
+func (v *Vedis) beginEvents() {
+	v.watchers.Lock()
+	v.watchers.inTx = true
+	v.watchers.Unlock()
+}
+
+func (v *Vedis) endEvents(commit bool) {
+	v.watchers.Lock()
+	events, touched := v.watchers.pending, v.watchers.touched
+	v.watchers.inTx, v.watchers.pending, v.watchers.touched = false, nil, nil
+	if !commit {
+		v.watchers.Unlock()
+		return
+	}
+	v.watchers.bump(touched)
+	list := v.watchers.list
+	v.watchers.Unlock()
+	dispatch(list, events)
+}
+
+// Bumps the version of the watched keys among keys.
+// Must be called with the lock held.
+func (w *watchers) bump(keys []string) {
+	for _, key := range keys {
+		if version, ok := w.versions[key]; ok {
+			version.value++
+		}
+	}
+}
+
+func dispatch(list []*watcher, events []Event) {
+	for _, event := range events {
+		for _, w := range list {
+			if strings.HasPrefix(event.Key, w.prefix) {
+				w.fn(event)
+			}
+		}
+	}
+}
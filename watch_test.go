@@ -0,0 +1,96 @@
+package vedis
+
+import (
+	"bytes"
+	"strings"
+)
+
+func (suite *VedisTestSuite) TestWatch() {
+	var events []Event
+	unwatch := suite.store.Watch("user:", func(e Event) {
+		events = append(events, e)
+	})
+
+	suite.store.Set("user:1", "John")
+	suite.store.Set("config", "ignored")
+	suite.store.Append("user:1", " Smith")
+	suite.store.HSet("user:2", "name", "Mary")
+	suite.store.Incr("user:count")
+	suite.store.Del("user:1")
+	suite.store.Del("user:nothing")
+
+	suite.Equal([]Event{
+		{Key: "user:1", Op: "SET", New: "John"},
+		{Key: "user:1", Op: "APPEND", Old: "John", New: "John Smith"},
+		{Key: "user:2", Field: "name", Op: "HSET", New: "Mary"},
+		{Key: "user:count", Op: "INCR", New: "1"},
+		{Key: "user:1", Op: "DEL", Old: "John Smith"},
+	}, events)
+
+	unwatch()
+	suite.store.Set("user:1", "John")
+	suite.Len(events, 5)
+}
+
+func (suite *VedisTestSuite) TestWatchLoads() {
+	suite.store.Set("name", "John")
+	suite.store.HSet("config", "url", "github.com")
+	var buffer bytes.Buffer
+	suite.store.Dump(&buffer)
+	suite.store.Set("name", "Jane")
+
+	var events []Event
+	suite.store.Watch("", func(e Event) {
+		events = append(events, e)
+	})
+	if err := suite.store.Restore(&buffer); err != nil {
+		suite.Fail(err.Error())
+	}
+	if err := suite.store.ImportJSON(strings.NewReader(`{"key": "name", "type": "string", "value": "Jim"}`)); err != nil {
+		suite.Fail(err.Error())
+	}
+
+	suite.Equal([]Event{
+		{Key: "name", Op: "RESTORE", Old: "Jane", New: "John"},
+		{Key: "config", Op: "RESTORE"},
+		{Key: "name", Op: "IMPORT", Old: "John", New: "Jim"},
+	}, events)
+}
+
+func (suite *VedisTestSuite) TestWatchTransaction() {
+	var events []Event
+	suite.store.Watch("", func(e Event) {
+		events = append(events, e)
+	})
+
+	if ok, err := suite.store.Begin(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+
+	suite.store.Set("name", "John")
+	suite.Empty(events)
+
+	if ok, err := suite.store.Commit(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+	suite.Equal([]Event{{Key: "name", Op: "SET", New: "John"}}, events)
+
+	if ok, err := suite.store.Begin(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+
+	suite.store.Set("name", "Smith")
+
+	if ok, err := suite.store.Rollback(); err != nil {
+		suite.Fail(err.Error())
+	} else {
+		suite.True(ok)
+	}
+	suite.Len(events, 1)
+}